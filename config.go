@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// config holds the settings for starting nvim. It is read from the config
+// file, and flags override it.
+type config struct {
+	Nvim    string            `toml:"nvim"`    // nvim executable, looked up in PATH if not a path
+	Args    []string          `toml:"args"`    // extra arguments for nvim
+	AppName string            `toml:"appname"` // NVIM_APPNAME, selects the nvim config directory
+	Env     map[string]string `toml:"env"`     // environment variables to set for nvim
+	Dir     string            `toml:"dir"`     // working directory for nvim
+}
+
+// stringsFlag is a flag that can be given several times.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, " ")
+}
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+var (
+	configFlag  = flag.String("config", defaultConfigPath(), "Config file")
+	nvimFlag    = flag.String("nvim", "", "nvim executable (default \"nvim\")")
+	appNameFlag = flag.String("appname", "", "NVIM_APPNAME for nvim, selects the nvim config directory")
+	dirFlag     = flag.String("dir", "", "Working directory for nvim (default the current directory)")
+	argFlag     stringsFlag
+	envFlag     stringsFlag
+)
+
+func init() {
+	flag.Var(&argFlag, "arg", "Extra argument for nvim, may be repeated")
+	flag.Var(&envFlag, "env", "KEY=VALUE environment variable for nvim, may be repeated")
+}
+
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "fynevim", "config.toml")
+}
+
+// loadConfig reads the config file, if there is one, and applies the flags
+// on top of it.
+func loadConfig() (config, error) {
+	c := config{
+		Nvim: "nvim",
+		Dir:  os.Getenv("PWD"),
+		Env:  map[string]string{},
+	}
+
+	if *configFlag != "" {
+		_, err := toml.DecodeFile(*configFlag, &c)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return c, fmt.Errorf("Error reading config %v: %w", *configFlag, err)
+		}
+	}
+
+	if *nvimFlag != "" {
+		c.Nvim = *nvimFlag
+	}
+	if *appNameFlag != "" {
+		c.AppName = *appNameFlag
+	}
+	if *dirFlag != "" {
+		c.Dir = *dirFlag
+	}
+	c.Args = append(c.Args, argFlag...)
+	for _, kv := range envFlag {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return c, fmt.Errorf("Invalid -env %q, expected KEY=VALUE", kv)
+		}
+		if c.Env == nil {
+			c.Env = map[string]string{}
+		}
+		c.Env[k] = v
+	}
+
+	return c, nil
+}
+
+// environ returns the environment for nvim, or nil to inherit fynevim's.
+func (c config) environ() []string {
+	if len(c.Env) == 0 && c.AppName == "" {
+		return nil
+	}
+
+	env := os.Environ()
+	for k, v := range c.Env {
+		env = append(env, k+"="+v)
+	}
+	if c.AppName != "" {
+		env = append(env, "NVIM_APPNAME="+c.AppName)
+	}
+	return env
+}
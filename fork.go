@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// forkStatusEnv tells a forked child that the parent waits for its startup
+// status on file descriptor 3.
+const forkStatusEnv = "FYNEVIM_FORK_STATUS_FD"
+
+// forkStatus is where a forked child reports its startup status, nil if
+// fynevim wasn't forked or the status was already reported.
+var forkStatus *os.File
+
+// fork starts fynevim again without the -fork flag, detached from the
+// terminal in a new session, with its output going to a log file. It waits
+// for the child to report that it started and returns the exit code.
+func fork() int {
+	program, err := os.Executable()
+	if err != nil {
+		program = os.Args[0]
+	}
+	args := filterForkFlag(os.Args[1:])
+
+	// Create a command that will execute the current program
+	cmd := exec.Command(program, args...)
+	dir, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fynevim: Error getting working directory: %v\n", err)
+		return exitError
+	}
+	cmd.Dir = dir
+
+	// Inherit the current process's environment variables
+	cmd.Env = os.Environ()
+
+	logFile, err := openForkLog()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fynevim: Error opening log file: %v\n", err)
+		return exitError
+	}
+	defer logFile.Close()
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	detach(cmd)
+
+	var status, statusW *os.File
+	if forkStatusSupported {
+		status, statusW, err = os.Pipe()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fynevim: Error forking: %v\n", err)
+			return exitError
+		}
+		defer status.Close()
+		cmd.ExtraFiles = []*os.File{statusW}
+		cmd.Env = append(cmd.Env, forkStatusEnv+"=3")
+	}
+
+	// Start the child process
+	err = cmd.Start()
+	if statusW != nil {
+		// only the child may hold the write end, so its exit ends the read
+		statusW.Close()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fynevim: Error forking: %v\n", err)
+		return exitError
+	}
+	if status == nil {
+		return exitOK
+	}
+
+	line, err := bufio.NewReader(status).ReadString('\n')
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fynevim: exited during startup, see %v\n", logFile.Name())
+		return exitError
+	}
+	return parseForkStatus(strings.TrimSuffix(line, "\n"))
+}
+
+// parseForkStatus prints the error in a status line reported by a forked
+// child, and returns its exit code.
+func parseForkStatus(line string) int {
+	if line == "ok" {
+		return exitOK
+	}
+
+	fields := strings.SplitN(line, " ", 3)
+	if len(fields) < 3 || fields[0] != "error" {
+		fmt.Fprintf(os.Stderr, "fynevim: unexpected startup status %q\n", line)
+		return exitError
+	}
+	code, err := strconv.Atoi(fields[1])
+	if err != nil {
+		code = exitError
+	}
+	fmt.Fprintf(os.Stderr, "fynevim: %v\n", fields[2])
+	return code
+}
+
+// initForkStatus picks up the status file descriptor in a forked child.
+func initForkStatus() {
+	fd := os.Getenv(forkStatusEnv)
+	if fd == "" {
+		return
+	}
+	os.Unsetenv(forkStatusEnv) // not for nvim
+	n, err := strconv.Atoi(fd)
+	if err != nil {
+		return
+	}
+	closeOnExec(n) // nvim mustn't keep the parent waiting
+	forkStatus = os.NewFile(uintptr(n), "fork-status")
+}
+
+// reportForkStatus tells the parent of a forked child whether startup
+// succeeded, err is nil if it did. Only the first report is sent.
+func reportForkStatus(err error, code int) {
+	if forkStatus == nil {
+		return
+	}
+	if err == nil {
+		fmt.Fprintln(forkStatus, "ok")
+	} else {
+		msg := strings.ReplaceAll(err.Error(), "\n", " ")
+		fmt.Fprintf(forkStatus, "error %d %s\n", code, msg)
+	}
+	forkStatus.Close()
+	forkStatus = nil
+}
+
+// openForkLog opens the log file for a forked child's output.
+func openForkLog() (*os.File, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	dir = filepath.Join(dir, "fynevim")
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+	return os.OpenFile(filepath.Join(dir, "fynevim.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+}
+
+func filterForkFlag(args []string) []string {
+	n := 0
+	for _, x := range args {
+		if x != "-fork" && x != "--fork" {
+			args[n] = x
+			n++
+		}
+	}
+	return args[:n]
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+
+package main
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+const forkStatusSupported = true
+
+// detach starts cmd in a new session, so closing the terminal fynevim was
+// started from doesn't end it.
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+func closeOnExec(fd int) {
+	syscall.CloseOnExec(fd)
+}
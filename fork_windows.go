@@ -0,0 +1,20 @@
+package main
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// forkStatusSupported is false as exec.Cmd.ExtraFiles isn't supported on
+// Windows, the parent doesn't wait for the child's startup status.
+const forkStatusSupported = false
+
+// detach starts cmd without the console fynevim was started from.
+func detach(cmd *exec.Cmd) {
+	const detachedProcess = 0x00000008
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: detachedProcess | syscall.CREATE_NEW_PROCESS_GROUP,
+	}
+}
+
+func closeOnExec(fd int) {}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+
+	fynevim "github.com/gjkliewer/fynevim/widget"
+)
+
+// preference keys for the size of the last closed window
+const (
+	prefWindowWidth  = "window.width"
+	prefWindowHeight = "window.height"
+)
+
+// sizeFlag is a WIDTHxHEIGHT flag, form names the dimensions in errors.
+type sizeFlag struct {
+	form          string
+	width, height int
+}
+
+func (s *sizeFlag) String() string {
+	if !s.isSet() {
+		return ""
+	}
+	return fmt.Sprintf("%dx%d", s.width, s.height)
+}
+
+func (s *sizeFlag) Set(value string) error {
+	var width, height int
+	_, err := fmt.Sscanf(value, "%dx%d", &width, &height)
+	if err != nil || width <= 0 || height <= 0 {
+		return fmt.Errorf("expected %v, got %q", s.form, value)
+	}
+	s.width, s.height = width, height
+	return nil
+}
+
+func (s *sizeFlag) isSet() bool {
+	return s.width > 0 && s.height > 0
+}
+
+// windowSize returns the size of a new window: -geometry, -size, the size of
+// the last closed window or the default.
+func windowSize(a fyne.App) fyne.Size {
+	if geometryFlag.isSet() {
+		cell := fynevim.CellSize()
+		return fyne.NewSize(cell.Width*float32(geometryFlag.width), cell.Height*float32(geometryFlag.height))
+	}
+	if windowSizeFlag.isSet() {
+		return fyne.NewSize(float32(windowSizeFlag.width), float32(windowSizeFlag.height))
+	}
+	p := a.Preferences()
+	return fyne.NewSize(
+		float32(p.FloatWithFallback(prefWindowWidth, defaultWinWidth)),
+		float32(p.FloatWithFallback(prefWindowHeight, defaultWinHeight)),
+	)
+}
+
+// gridSize returns the number of columns and rows an editor of size shows.
+func gridSize(size fyne.Size) (cols, rows int) {
+	cell := fynevim.CellSize()
+	return int(size.Width / cell.Width), int(size.Height / cell.Height)
+}
+
+// saveWindowSize remembers the size of window for the next launch, unless it
+// is fullscreen.
+func saveWindowSize(a fyne.App, window fyne.Window) {
+	if window.FullScreen() {
+		return
+	}
+	size := window.Canvas().Size()
+	if size.IsZero() {
+		return
+	}
+	p := a.Preferences()
+	p.SetFloat(prefWindowWidth, float64(size.Width))
+	p.SetFloat(prefWindowHeight, float64(size.Height))
+}
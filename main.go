@@ -1,14 +1,18 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
 	"os/exec"
+	"path/filepath"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/dialog"
 
 	"github.com/neovim/go-client/nvim"
 
@@ -17,77 +21,227 @@ import (
 
 const defaultWinWidth = 1000
 const defaultWinHeight = 800
+const defaultTitle = "Neovim"
+
+// appID identifies fynevim's preferences.
+const appID = "io.github.gjkliewer.fynevim"
 
 var log *slog.Logger
 
+var (
+	cursorAnimationFlag = flag.Duration("cursor-animation", 0, "Animate cursor movement over this duration, e.g. 80ms")
+	scrollAnimationFlag = flag.Duration("scroll-animation", 0, "Animate scrolling over this duration, e.g. 150ms")
+	busySpinnerFlag     = flag.Bool("busy-spinner", false, "Show a spinner in place of the cursor while nvim is busy")
+	serverFlag          = flag.String("server", "", "Attach to the nvim server at this Unix socket or host:port instead of starting nvim, see nvim --listen")
+	remoteFlag          = flag.Bool("remote", false, "Open the files in the running fynevim, or start one if there is none")
+	fullscreenFlag      = flag.Bool("fullscreen", false, "Start in fullscreen")
+	fullscreenKeyFlag   = flag.String("fullscreen-key", string(fyne.KeyF11), "Key that toggles fullscreen, e.g. F12, empty to disable")
+	bellFlag            fynevim.BellStyle
+	geometryFlag        = sizeFlag{form: "COLSxROWS"}
+	windowSizeFlag      = sizeFlag{form: "WIDTHxHEIGHT"}
+)
+
+func init() {
+	flag.Var(&bellFlag, "bell", "Response to nvim's bell: flash, beep or none")
+	flag.Var(&geometryFlag, "geometry", "Window size in columns and rows, e.g. 100x40")
+	flag.Var(&windowSizeFlag, "size", "Window size in pixels, e.g. 1200x900")
+}
+
 func main() {
 	forkFlag := flag.Bool("fork", false, "Spawn a child process")
 	flag.Parse()
 
-	if *forkFlag {
-		program := os.Args[0]
-		args := filterForkFlag(os.Args[1:])
-
-		// Create a command that will execute the current program
-		cmd := exec.Command(program, args...)
-		dir, err := os.Getwd()
-		if err != nil {
-			panic(fmt.Sprintf("Error getting working directory: %v", err))
+	if *remoteFlag {
+		err := openRemote(flag.Args())
+		if err == nil {
+			os.Exit(exitOK)
 		}
-		cmd.Dir = dir
-
-		// Inherit the current process's environment variables
-		cmd.Env = os.Environ()
-
-		// Start the child process
-		if err := cmd.Start(); err != nil {
-			panic(fmt.Sprintf("Error forking: %v", err))
+		if !errors.Is(err, errNoInstance) {
+			fmt.Fprintf(os.Stderr, "fynevim: %v\n", err)
+			os.Exit(exitError)
 		}
-	} else {
-		startApp()
 	}
+
+	if *forkFlag {
+		os.Exit(fork())
+	}
+
+	initForkStatus()
+	os.Exit(startApp())
 }
 
-func startApp() {
+// exit codes
+const (
+	exitOK           = 0
+	exitError        = 1
+	exitNvimNotFound = 127
+)
+
+// startApp runs the editor windows and returns the exit code. The app quits
+// when the last window is closed.
+func startApp() int {
 	log = initLogger()
-	a := app.New()
-	window := a.NewWindow("Neovim")
-	window.SetPadded(false)
-	window.Resize(fyne.NewSize(defaultWinWidth, defaultWinHeight))
+	a := app.NewWithID(appID)
 
-	dir := os.Getenv("PWD")
+	c, err := loadConfig()
+	if err != nil {
+		return showFatalError(newWindow(a, windowSize(a)), err, exitError)
+	}
 
-	childProcessArgs := append([]string{"--embed"}, os.Args[1:]...)
+	window, code, err := openEditorWindow(a, c, flag.Args())
+	if err != nil {
+		return showFatalError(newWindow(a, windowSize(a)), err, code)
+	}
+	reportForkStatus(nil, exitOK)
+	log.Debug("starting window")
+	window.Show()
+	a.Run()
+	return exitOK
+}
 
-	editor := fynevim.NewEditor(
-		log,
-		[]nvim.ChildProcessOption{
-			nvim.ChildProcessCommand("nvim"), // nvim must be in PATH
-			nvim.ChildProcessArgs(childProcessArgs...),
-			nvim.ChildProcessDir(dir),
-			nvim.ChildProcessLogf(log.Debug),
-		},
-	)
-	defer editor.Nvim.Close()
+func newWindow(a fyne.App, size fyne.Size) fyne.Window {
+	window := a.NewWindow(defaultTitle)
+	window.SetPadded(false)
+	window.Resize(size)
+	window.SetFullScreen(*fullscreenFlag)
+	return window
+}
 
-	cID := editor.Nvim.ChannelID()
-	err := editor.Nvim.Command(fmt.Sprintf("autocmd VimLeave * call rpcnotify(%v, 'fynevim.VimLeave')", cID))
+// openEditorWindow returns a new window with its own editor, which starts nvim
+// with args or attaches to the -server. If the editor can't be created it
+// returns the error and the exit code for it.
+func openEditorWindow(a fyne.App, c config, args []string) (fyne.Window, int, error) {
+	size := windowSize(a)
+	if *serverFlag == "" {
+		if err := checkDir(c.Dir); err != nil {
+			return nil, exitError, err
+		}
+	}
+	editor, err := newEditor(c, args, size)
+	if nvimNotFound(err, c.Nvim) {
+		err = fmt.Errorf("nvim not found at %q, configure its path with -nvim or in %v", c.Nvim, *configFlag)
+		return nil, exitNvimNotFound, err
+	}
 	if err != nil {
-		panic(fmt.Sprintf("Could set autocmd: %v", err))
+		return nil, exitError, err
 	}
 
-	err = editor.Nvim.RegisterHandler("fynevim.VimLeave", func() error {
-		window.Close()
-		return nil
+	window := newWindow(a, size)
+	editor.CursorAnimation = *cursorAnimationFlag
+	editor.ScrollAnimation = *scrollAnimationFlag
+	editor.Bell = bellFlag
+	editor.BusySpinner = *busySpinnerFlag
+	editor.FullscreenKey = fyne.KeyName(*fullscreenKeyFlag)
+	editor.OnTitleChanged = func(title string) {
+		if title == "" {
+			title = defaultTitle
+		}
+		window.SetTitle(title)
+	}
+
+	// each window closes on its own, nvim exiting in one doesn't affect the
+	// others
+	editor.OnDetach = window.Close
+	editor.OnExit = window.Close
+	window.SetOnClosed(func() {
+		saveWindowSize(a, window)
+		editor.Close()
 	})
-	if err != nil {
-		panic(fmt.Sprintf("Could not register handler: %v", err))
+
+	editor.OnNewWindow = func() {
+		log.Debug("opening new window")
+		w, _, err := openEditorWindow(a, c, nil)
+		if err != nil {
+			log.Error("error opening window", "error", err)
+			dialog.ShowError(err, window)
+			return
+		}
+		w.Show()
 	}
 
 	window.SetContent(editor)
-	log.Debug("starting window")
+	window.SetOnDropped(editor.Dropped)
 	window.Canvas().Focus(editor)
+	return window, exitOK, nil
+}
+
+// checkDir reports a missing working directory for nvim, which would
+// otherwise fail to start with the same error as a missing nvim.
+func checkDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	info, err := os.Stat(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("working directory %q not found, configure it with -dir or in %v", dir, *configFlag)
+	}
+	if err != nil {
+		return fmt.Errorf("working directory %q can't be opened: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("working directory %q is not a directory", dir)
+	}
+	return nil
+}
+
+// nvimNotFound reports whether err is from starting the nvim at path, which
+// doesn't exist. nvim is looked up in PATH unless path has a separator, in
+// which case starting it fails with the file's error.
+func nvimNotFound(err error, path string) bool {
+	var execErr *exec.Error
+	if errors.As(err, &execErr) {
+		return true
+	}
+	if filepath.Base(path) == path || !errors.Is(err, fs.ErrNotExist) {
+		return false
+	}
+	_, err = os.Stat(path)
+	return errors.Is(err, fs.ErrNotExist)
+}
+
+// newEditor returns an editor for a new nvim started with args, or attached to
+// the -server, which new windows share. nvim starts with a grid that fills
+// size.
+func newEditor(c config, args []string, size fyne.Size) (*fynevim.Editor, error) {
+	opts := fynevim.Options{Log: log}
+	opts.Cols, opts.Rows = gridSize(size)
+	if *serverFlag != "" {
+		opts.ServerAddress = *serverFlag
+		opts.DialOptions = []nvim.DialOption{nvim.DialLogf(log.Debug)}
+		return fynevim.NewEditorWithOptions(opts)
+	}
+
+	childProcessArgs := append([]string{"--embed"}, c.Args...)
+	childProcessArgs = append(childProcessArgs, args...)
+
+	opts.Command = func() *exec.Cmd {
+		nvimArgs := childProcessArgs
+		// the first fynevim receives the files of fynevim -remote
+		if sock := instanceSocket(); claimInstanceSocket(sock) {
+			nvimArgs = append([]string{"--listen", sock}, nvimArgs...)
+		}
+		cmd := exec.Command(c.Nvim, nvimArgs...)
+		cmd.Dir = c.Dir
+		cmd.Env = c.environ()
+		return cmd
+	}
+	return fynevim.NewEditorWithOptions(opts)
+}
+
+// showFatalError shows err in window until the user dismisses it, or reports
+// it to the parent if fynevim was forked, and returns code.
+func showFatalError(window fyne.Window, err error, code int) int {
+	log.Error("fatal error", "error", err)
+	if forkStatus != nil {
+		// the parent shows the error in its terminal
+		reportForkStatus(err, code)
+		return code
+	}
+	d := dialog.NewError(err, window)
+	d.SetOnClosed(window.Close)
+	d.Show()
 	window.ShowAndRun()
+	return code
 }
 
 func logLevel() slog.Level {
@@ -106,14 +260,3 @@ func initLogger() *slog.Logger {
 	l := slog.New(h)
 	return l
 }
-
-func filterForkFlag(args []string) []string {
-	n := 0
-	for _, x := range args {
-		if x != "-fork" {
-			args[n] = x
-			n++
-		}
-	}
-	return args[:n]
-}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/neovim/go-client/nvim"
+)
+
+// errNoInstance is returned by openRemote when no fynevim is running.
+var errNoInstance = errors.New("no running fynevim")
+
+// remoteOpen raises the window of the fynevim attached to nvim and opens the
+// paths with :drop. The window is raised first, opening a file can block on a
+// prompt.
+const remoteOpen = `
+local paths = ...
+if vim.g.fynevim_channel then
+  pcall(vim.rpcnotify, vim.g.fynevim_channel, 'fynevim.RequestFocus')
+end
+if #paths > 0 then
+  vim.cmd('drop ' .. table.concat(vim.tbl_map(vim.fn.fnameescape, paths), ' '))
+end
+`
+
+// instanceSocket returns the per-user socket that the nvim of the first
+// standalone fynevim listens on, or "" if there is none. nvim only listens on
+// named pipes on Windows, which the go client can't dial.
+func instanceSocket() string {
+	if runtime.GOOS == "windows" {
+		return ""
+	}
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		var err error
+		dir, err = os.UserCacheDir()
+		if err != nil {
+			return ""
+		}
+	}
+	// a private directory, so other users can't take the socket's place
+	dir = filepath.Join(dir, "fynevim")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "nvim.sock")
+}
+
+// claimInstanceSocket reports whether nvim can listen on path, removing the
+// socket a crashed instance left behind. It is false while another fynevim
+// listens on it.
+func claimInstanceSocket(path string) bool {
+	if path == "" {
+		return false
+	}
+	conn, err := net.Dial("unix", path)
+	if err == nil {
+		conn.Close()
+		return false
+	}
+	err = os.Remove(path)
+	return err == nil || errors.Is(err, os.ErrNotExist)
+}
+
+// openRemote opens paths in the running fynevim and raises its window. It
+// returns errNoInstance if no fynevim listens on the instance socket.
+func openRemote(paths []string) error {
+	sock := instanceSocket()
+	if sock == "" {
+		return errNoInstance
+	}
+	v, err := nvim.Dial(sock)
+	if err != nil {
+		return errNoInstance
+	}
+	defer v.Close()
+
+	// the running nvim has its own working directory
+	abs := make([]string, len(paths))
+	for i, p := range paths {
+		abs[i], err = filepath.Abs(p)
+		if err != nil {
+			return fmt.Errorf("Error opening %v: %w", p, err)
+		}
+	}
+	err = v.ExecLua(remoteOpen, nil, abs)
+	if err != nil {
+		return fmt.Errorf("Error opening files in running fynevim: %w", err)
+	}
+	return nil
+}
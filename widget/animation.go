@@ -0,0 +1,176 @@
+package widget
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// scrollAnimation slides the rows of a grid_scroll region into place. The
+// strip holds the rows scrolled out of view next to the region's current
+// rows and is moved through clip, which hides everything outside the region.
+type scrollAnimation struct {
+	clip  *container.Scroll
+	strip *widget.TextGrid
+	anim  *fyne.Animation
+
+	region GridScroll           // scrolled region, Rows is the total scroll
+	old    []widget.TextGridRow // rows scrolled out of the region
+}
+
+func newScrollAnimation() scrollAnimation {
+	strip := widget.NewTextGrid()
+	clip := container.NewScroll(strip)
+	clip.Direction = container.ScrollNone
+	clip.Hide()
+	return scrollAnimation{clip: clip, strip: strip}
+}
+
+// setPos moves the cursor to pos without animating it.
+func (c *cursor) setPos(pos fyne.Position) {
+	c.pos = pos
+	c.image.Move(pos)
+	c.text.Move(pos)
+}
+
+// moveCursor places the cursor at pos, sliding it there from its current
+// position if CursorAnimation is set.
+func (e *Editor) moveCursor(pos fyne.Position) {
+	c := &e.cursor
+	if c.anim != nil {
+		if pos == c.target {
+			return
+		}
+		c.anim.Stop()
+		c.anim = nil
+	}
+	c.target = pos
+
+	if e.CursorAnimation <= 0 || !c.drawn || c.pos == pos {
+		c.drawn = true
+		c.setPos(pos)
+		return
+	}
+
+	c.anim = canvas.NewPositionAnimation(c.pos, pos, e.CursorAnimation, c.setPos)
+	c.anim.Curve = fyne.AnimationEaseOut
+	c.anim.Start()
+}
+
+// prepareScrollAnimation remembers the rows that the pending grid scrolls will
+// move out of view. It must be called before the scrolls are applied to the
+// content grid. Scrolls covering different regions in the same flush, or
+// moving a whole region, are not animated.
+func (e *Editor) prepareScrollAnimation(scrolls []GridScroll) {
+	e.stopScrollAnimation()
+	if e.ScrollAnimation <= 0 || len(scrolls) == 0 {
+		return
+	}
+
+	region := scrolls[0]
+	region.Rows = 0
+	for _, gs := range scrolls {
+		if gs.Top != region.Top || gs.Bot != region.Bot || gs.Left != region.Left || gs.Right != region.Right {
+			return
+		}
+		region.Rows += gs.Rows
+	}
+
+	n := abs(region.Rows)
+	if n == 0 || n >= region.Bot-region.Top {
+		return
+	}
+
+	var from int
+	if region.Rows > 0 {
+		from = region.Top
+	} else {
+		from = region.Bot - n
+	}
+	e.scroll.region = region
+	e.scroll.old = e.copyRows(from, from+n, region.Left, region.Right)
+}
+
+// startScrollAnimation slides the region remembered by prepareScrollAnimation
+// from its old rows to the content grid's current rows.
+func (e *Editor) startScrollAnimation() {
+	s := &e.scroll
+	if s.old == nil {
+		return
+	}
+
+	cellSize := e.cellSize()
+	r := s.region
+	s.clip.Move(fyne.NewPos(cellSize.Width*float32(r.Left), cellSize.Height*float32(r.Top)))
+	s.clip.Resize(fyne.NewSize(cellSize.Width*float32(r.Right-r.Left), cellSize.Height*float32(r.Bot-r.Top)))
+	s.clip.Show()
+	e.updateScrollAnimation()
+
+	distance := cellSize.Height * float32(abs(r.Rows))
+	s.anim = fyne.NewAnimation(e.ScrollAnimation, func(progress float32) {
+		if r.Rows > 0 {
+			s.clip.Offset.Y = distance * progress
+		} else {
+			s.clip.Offset.Y = distance * (1 - progress)
+		}
+		s.clip.Refresh()
+		if progress == 1 {
+			s.clip.Hide()
+		}
+	})
+	s.anim.Curve = fyne.AnimationEaseOut
+	s.anim.Start()
+}
+
+// updateScrollAnimation rebuilds the strip from the old rows and the content
+// grid, so that lines redrawn while the animation runs are shown in it.
+func (e *Editor) updateScrollAnimation() {
+	s := &e.scroll
+	if s.old == nil || !s.clip.Visible() {
+		return
+	}
+
+	r := s.region
+	current := e.copyRows(r.Top, r.Bot, r.Left, r.Right)
+	if r.Rows > 0 {
+		s.strip.Rows = append(append([]widget.TextGridRow{}, s.old...), current...)
+	} else {
+		s.strip.Rows = append(current, s.old...)
+	}
+	s.strip.Refresh()
+}
+
+// stopScrollAnimation ends a running scroll animation, leaving the content
+// grid visible as it is.
+func (e *Editor) stopScrollAnimation() {
+	s := &e.scroll
+	if s.anim != nil {
+		s.anim.Stop()
+		s.anim = nil
+	}
+	s.old = nil
+	s.clip.Hide()
+}
+
+// copyRows returns a copy of the cells in rows top to bot and columns left to
+// right of the content grid.
+func (e *Editor) copyRows(top, bot, left, right int) []widget.TextGridRow {
+	var rows []widget.TextGridRow
+	for r := top; r < bot; r++ {
+		row := e.content.Row(r)
+		copied := widget.TextGridRow{Style: row.Style}
+		if left < len(row.Cells) {
+			copied.Cells = append(copied.Cells, row.Cells[left:min(right, len(row.Cells))]...)
+		}
+		rows = append(rows, copied)
+	}
+	return rows
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
@@ -0,0 +1,8 @@
+package widget
+
+import "os/exec"
+
+// beep plays the system alert sound.
+func beep() error {
+	return exec.Command("osascript", "-e", "beep").Run()
+}
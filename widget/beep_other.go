@@ -0,0 +1,18 @@
+//go:build !darwin && !windows
+
+package widget
+
+import (
+	"os"
+	"os/exec"
+)
+
+// beep plays the desktop's bell sound through libcanberra, falling back to
+// ringing the terminal fynevim was started from.
+func beep() error {
+	if path, err := exec.LookPath("canberra-gtk-play"); err == nil {
+		return exec.Command(path, "--id=bell").Run()
+	}
+	_, err := os.Stderr.WriteString("\a")
+	return err
+}
@@ -0,0 +1,14 @@
+package widget
+
+import "syscall"
+
+var messageBeep = syscall.NewLazyDLL("user32.dll").NewProc("MessageBeep")
+
+// beep plays the system alert sound.
+func beep() error {
+	const mbOK = 0x00000000
+	if ok, _, err := messageBeep.Call(mbOK); ok == 0 {
+		return err
+	}
+	return nil
+}
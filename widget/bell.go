@@ -0,0 +1,90 @@
+package widget
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+)
+
+const bellFlashDuration = 150 * time.Millisecond
+const bellFlashAlpha = 0x40
+
+// BellStyle selects how the editor responds to nvim's bell.
+type BellStyle int
+
+const (
+	// BellFlash briefly flashes the editor.
+	BellFlash BellStyle = iota
+	// BellBeep plays the system's alert sound. A visual bell still flashes.
+	BellBeep
+	// BellNone ignores the bell.
+	BellNone
+)
+
+var bellStyleNames = map[BellStyle]string{
+	BellFlash: "flash",
+	BellBeep:  "beep",
+	BellNone:  "none",
+}
+
+// String implements flag.Value.
+func (b *BellStyle) String() string {
+	if b == nil {
+		return bellStyleNames[BellFlash]
+	}
+	return bellStyleNames[*b]
+}
+
+// Set implements flag.Value, it accepts "flash", "beep" or "none".
+func (b *BellStyle) Set(s string) error {
+	for style, name := range bellStyleNames {
+		if name == s {
+			*b = style
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown bell style %q", s)
+}
+
+// ring responds to a bell event, visual is set for the visual_bell event.
+func (e *Editor) ring(visual bool) {
+	e.debug("bell", "visual", visual, "style", e.Bell.String())
+	if e.OnBell != nil {
+		e.OnBell(visual)
+	}
+
+	switch {
+	case e.Bell == BellNone:
+	case e.Bell == BellBeep && !visual:
+		go func() {
+			if err := beep(); err != nil {
+				e.debug("error playing bell", "error", err)
+			}
+		}()
+	default:
+		e.flashBell()
+	}
+}
+
+// flashBell fades an overlay of the foreground color out over the editor.
+func (e *Editor) flashBell() {
+	if e.bellAnim != nil {
+		e.bellAnim.Stop()
+	}
+
+	fill := color.NRGBAModel.Convert(theme.ForegroundColor()).(color.NRGBA)
+	e.bellFlash.Show()
+	e.bellAnim = fyne.NewAnimation(bellFlashDuration, func(progress float32) {
+		fill.A = uint8(bellFlashAlpha * (1 - progress))
+		e.bellFlash.FillColor = fill
+		e.bellFlash.Refresh()
+		if progress == 1 {
+			e.bellFlash.Hide()
+		}
+	})
+	e.bellAnim.Curve = fyne.AnimationEaseOut
+	e.bellAnim.Start()
+}
@@ -0,0 +1,192 @@
+package widget
+
+import (
+	"runtime"
+	"strings"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+)
+
+// pasteChunkSize is the most bytes sent in one nvim_paste call, larger
+// pastes are streamed in phases.
+const pasteChunkSize = 64 * 1024
+
+// clipboardProvider makes nvim use the editor for the + and * registers, see
+// :help clipboard-tool. An embedded nvim gets it before sourcing the user's
+// config, so a g:clipboard set there still takes precedence. A server keeps
+// its own g:clipboard unless a previous fynevim set it. nvim reads
+// g:clipboard when it loads the clipboard provider, so a server that already
+// loaded it loads it again. The channel is looked up on each call, so the
+// provider follows the editor when it reattaches.
+const clipboardProvider = `
+vim.g.fynevim_channel = ...
+if vim.g.clipboard ~= nil and vim.g.clipboard.name ~= 'fynevim' then
+  return
+end
+local function copy(lines, regtype)
+  vim.rpcrequest(vim.g.fynevim_channel, 'fynevim.ClipboardCopy', lines, regtype)
+end
+local function paste()
+  return vim.rpcrequest(vim.g.fynevim_channel, 'fynevim.ClipboardPaste')
+end
+vim.g.clipboard = {
+  name = 'fynevim',
+  copy = { ['+'] = copy, ['*'] = copy },
+  paste = { ['+'] = paste, ['*'] = paste },
+}
+if vim.g.loaded_clipboard_provider ~= nil then
+  vim.g.loaded_clipboard_provider = nil
+  vim.cmd('runtime autoload/provider/clipboard.vim')
+end
+`
+
+// clipboardRegister is the register type of the last copy, fyne's clipboard
+// only holds text so it is kept to paste the same text back linewise or
+// blockwise.
+type clipboardRegister struct {
+	text    string
+	regtype string
+}
+
+func (e *Editor) registerClipboardProvider() error {
+	err := e.Nvim.RegisterHandler("fynevim.ClipboardCopy", e.clipboardCopy)
+	if err != nil {
+		return err
+	}
+	err = e.Nvim.RegisterHandler("fynevim.ClipboardPaste", e.clipboardPaste)
+	if err != nil {
+		return err
+	}
+	return e.Nvim.ExecLua(clipboardProvider, nil, e.Nvim.ChannelID())
+}
+
+func (e *Editor) clipboardCopy(lines []string, regtype string) {
+	clipboard := e.clipboard()
+	if clipboard == nil {
+		e.debug("no window for clipboard copy")
+		return
+	}
+
+	text := strings.Join(lines, "\n")
+	if regtype == "V" {
+		text += "\n"
+	}
+	clipboard.SetContent(text)
+	e.clipboardRegister = clipboardRegister{text: text, regtype: regtype}
+}
+
+// clipboardPaste returns the clipboard as [lines, regtype]. An empty regtype
+// lets nvim pick one, lines that end in a newline are pasted linewise.
+func (e *Editor) clipboardPaste() ([]any, error) {
+	var text string
+	if clipboard := e.clipboard(); clipboard != nil {
+		text = clipboard.Content()
+	}
+
+	var regtype string
+	if text == e.clipboardRegister.text {
+		regtype = e.clipboardRegister.regtype
+	}
+
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	if regtype == "V" {
+		lines = lines[:len(lines)-1]
+	}
+	return []any{lines, regtype}, nil
+}
+
+// clipboard returns the clipboard of the editor's window.
+func (e *Editor) clipboard() fyne.Clipboard {
+	w := e.window()
+	if w == nil {
+		return nil
+	}
+	return w.Clipboard()
+}
+
+// Paste sends the clipboard's text to nvim as a paste, see PasteText.
+func (e *Editor) Paste() {
+	clipboard := e.clipboard()
+	if clipboard == nil {
+		return
+	}
+	text := clipboard.Content()
+	go func() {
+		if err := e.PasteText(text); err != nil {
+			e.debug("error in nvim.Paste", "error", err)
+		}
+	}()
+}
+
+// pasteChunkLen returns the length of the next chunk of text to paste, at
+// most pasteChunkSize. Runes and CRLF line endings aren't split between
+// chunks, unless the chunk has no other place to split.
+func pasteChunkLen(text string) int {
+	n := min(pasteChunkSize, len(text))
+	for n > 0 && n < len(text) && (!utf8.RuneStart(text[n]) || text[n-1] == '\r') {
+		n--
+	}
+	if n == 0 {
+		return min(pasteChunkSize, len(text))
+	}
+	return n
+}
+
+// PasteText pastes text into nvim with nvim_paste, so it is inserted as is
+// instead of being typed through autoindent and mappings. Text larger than
+// pasteChunkSize is streamed in phases, which nvim shows progress for and
+// the user can cancel.
+func (e *Editor) PasteText(text string) error {
+	if len(text) <= pasteChunkSize {
+		_, err := e.Nvim.Paste(text, true, -1)
+		return err
+	}
+
+	phase := 1
+	for len(text) > 0 {
+		n := pasteChunkLen(text)
+		chunk := text[:n]
+		text = text[n:]
+		if len(text) == 0 {
+			phase = 3
+		}
+
+		ok, err := e.Nvim.Paste(chunk, true, phase)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			e.debug("paste cancelled")
+			return nil
+		}
+		phase = 2
+	}
+	return nil
+}
+
+// isPasteShortcut reports whether key is the paste shortcut, see
+// isGUIShortcut.
+func isPasteShortcut(key fyne.KeyName) bool {
+	return isGUIShortcut(key, fyne.KeyV)
+}
+
+// isGUIShortcut reports whether key, with the modifiers held, is the editor's
+// shortcut for want: Cmd+want on macOS and Ctrl+Shift+want elsewhere since
+// Ctrl+want belongs to nvim.
+func isGUIShortcut(key, want fyne.KeyName) bool {
+	if key != want {
+		return false
+	}
+	d, ok := fyne.CurrentApp().Driver().(desktop.Driver)
+	if !ok {
+		return false
+	}
+	modifiers := d.CurrentKeyModifiers()
+	if runtime.GOOS == "darwin" {
+		return modifiers == fyne.KeyModifierSuper
+	}
+	return modifiers == fyne.KeyModifierControl|fyne.KeyModifierShift
+}
@@ -0,0 +1,32 @@
+package widget
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPasteChunkLen(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"short", "hello", 5},
+		{"empty", "", 0},
+		{"exactly one chunk", strings.Repeat("a", pasteChunkSize), pasteChunkSize},
+		{"plain", strings.Repeat("a", pasteChunkSize+10), pasteChunkSize},
+		{"all carriage returns", strings.Repeat("\r", pasteChunkSize+10), pasteChunkSize},
+		{"all continuation bytes", strings.Repeat("\x80", pasteChunkSize+10), pasteChunkSize},
+		{"rune straddles the boundary", strings.Repeat("a", pasteChunkSize-1) + "é" + "b", pasteChunkSize - 1},
+		{"4-byte rune straddles the boundary", strings.Repeat("a", pasteChunkSize-2) + "😀" + "b", pasteChunkSize - 2},
+		{"rune ends at the boundary", strings.Repeat("a", pasteChunkSize-2) + "é" + "b", pasteChunkSize},
+		{"CRLF straddles the boundary", strings.Repeat("a", pasteChunkSize-1) + "\r\n" + "b", pasteChunkSize - 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pasteChunkLen(tt.text); got != tt.want {
+				t.Errorf("pasteChunkLen() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
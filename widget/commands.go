@@ -0,0 +1,85 @@
+package widget
+
+// editorCommands defines the ex commands of the editor. They run in the
+// editor whose channel is in g:fynevim_channel, which the focused window
+// sets, so when several windows share a server a command runs in the window
+// it was typed in. When that window's UI leaves, the variable moves to
+// another fynevim window. When the last one leaves a server, its clipboard
+// provider is removed, so that the server's own provider is used again. Every
+// editor defines the commands again, which replaces those of a previous
+// fynevim on a server.
+const editorCommands = `
+local function fallback(except)
+  for _, ui in ipairs(vim.api.nvim_list_uis()) do
+    local client = ui.chan and vim.api.nvim_get_chan_info(ui.chan).client
+    if ui.chan ~= except and client and client.name == 'fynevim' then
+      return ui.chan
+    end
+  end
+end
+vim.api.nvim_create_autocmd('UILeave', {
+  group = vim.api.nvim_create_augroup('fynevim_commands', {}),
+  callback = function()
+    local chan = vim.v.event.chan
+    local other = fallback(chan)
+    if chan == vim.g.fynevim_channel or other == nil then
+      vim.g.fynevim_channel = other
+    end
+    if other == nil and vim.g.clipboard ~= nil and vim.g.clipboard.name == 'fynevim' then
+      vim.g.clipboard = nil
+      if vim.g.loaded_clipboard_provider ~= nil then
+        vim.g.loaded_clipboard_provider = nil
+        vim.cmd('runtime autoload/provider/clipboard.vim')
+      end
+    end
+  end,
+})
+
+local function command(name, method, eval)
+  vim.api.nvim_create_user_command(name, function()
+    local chan = vim.g.fynevim_channel or error('no fynevim window is attached')
+    if eval then
+      vim.rpcrequest(chan, method, { cwd = vim.fn.getcwd(), file = vim.fn.expand('%:p') })
+    else
+      vim.rpcrequest(chan, method)
+    end
+  end, { nargs = 0, force = true })
+end
+command('Preview', 'fynevim.Preview')
+command('FynevimOpen', 'fynevim.Open', true)
+command('FynevimSaveAs', 'fynevim.SaveAs', true)
+command('FynevimCd', 'fynevim.Cd', true)
+command('FynevimDetach', 'fynevim.Detach')
+command('FynevimNewWindow', 'fynevim.NewWindow')
+command('FynevimFullscreen', 'fynevim.Fullscreen')
+`
+
+// registerCommands handles the editor's ex commands on its channel and
+// defines them in nvim.
+func (e *Editor) registerCommands() error {
+	handlers := map[string]any{
+		"fynevim.Preview":    e.togglePreview,
+		"fynevim.Open":       e.openFileDialog,
+		"fynevim.SaveAs":     e.saveFileDialog,
+		"fynevim.Cd":         e.folderDialog,
+		"fynevim.Detach":     e.detachCommand,
+		"fynevim.NewWindow":  e.newWindowCommand,
+		"fynevim.Fullscreen": e.fullscreenCommand,
+	}
+	for method, fn := range handlers {
+		err := e.Nvim.RegisterHandler(method, fn)
+		if err != nil {
+			return err
+		}
+	}
+	return e.Nvim.ExecLua(editorCommands, nil)
+}
+
+// claimCommands makes the editor's window the one that runs the ex commands,
+// it is called when the editor gains focus.
+func (e *Editor) claimCommands() {
+	err := e.Nvim.SetVar("fynevim_channel", e.Nvim.ChannelID())
+	if err != nil {
+		e.debug("error claiming commands", "error", err)
+	}
+}
@@ -0,0 +1,141 @@
+package widget
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+var errNoFileName = errors.New("enter a file name")
+
+// fileDialogEval is sent by the file dialog commands, to start the dialog
+// where the user is.
+type fileDialogEval struct {
+	Cwd  string `msgpack:"cwd"`
+	File string `msgpack:"file"` // the current buffer's file
+}
+
+func (e *Editor) openFileDialog(eval *fileDialogEval) error {
+	w := e.window()
+	if w == nil {
+		return errNoWindow
+	}
+
+	d := dialog.NewFileOpen(func(r fyne.URIReadCloser, err error) {
+		if err != nil || r == nil {
+			return
+		}
+		r.Close()
+		e.runFileCommand("drop", r.URI().Path())
+	}, w)
+	e.setDialogLocation(d, eval.Cwd)
+	d.Show()
+	return nil
+}
+
+// saveFileDialog asks for a folder and a file name to save the buffer as.
+// fyne's file save dialog creates the file before returning it, which would
+// truncate an existing file even if nvim then fails to write it, so this
+// only picks the path and nvim does the writing.
+func (e *Editor) saveFileDialog(eval *fileDialogEval) error {
+	w := e.window()
+	if w == nil {
+		return errNoWindow
+	}
+
+	dir := eval.Cwd
+	name := widget.NewEntry()
+	if eval.File != "" {
+		dir = filepath.Dir(eval.File)
+		name.SetText(filepath.Base(eval.File))
+	}
+	name.Validator = func(s string) error {
+		if s == "" {
+			return errNoFileName
+		}
+		return nil
+	}
+
+	folder := widget.NewButtonWithIcon(dir, theme.FolderOpenIcon(), nil)
+	folder.Alignment = widget.ButtonAlignLeading
+	folder.OnTapped = func() {
+		d := dialog.NewFolderOpen(func(l fyne.ListableURI, err error) {
+			if err != nil || l == nil {
+				return
+			}
+			dir = l.Path()
+			folder.SetText(dir)
+		}, w)
+		e.setDialogLocation(d, dir)
+		d.Show()
+	}
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Folder", folder),
+		widget.NewFormItem("Name", name),
+	}
+	d := dialog.NewForm("Save As", "Save", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		path := filepath.Join(dir, name.Text)
+		if _, err := os.Stat(path); err != nil {
+			e.runFileCommand("saveas", path)
+			return
+		}
+		msg := fmt.Sprintf("%v already exists, replace it?", filepath.Base(path))
+		dialog.ShowConfirm("Replace File", msg, func(replace bool) {
+			if replace {
+				e.runFileCommand("saveas!", path)
+			}
+		}, w)
+	}, w)
+	d.Resize(fyne.NewSize(480, d.MinSize().Height))
+	d.Show()
+	w.Canvas().Focus(name)
+	return nil
+}
+
+func (e *Editor) folderDialog(eval *fileDialogEval) error {
+	w := e.window()
+	if w == nil {
+		return errNoWindow
+	}
+
+	d := dialog.NewFolderOpen(func(l fyne.ListableURI, err error) {
+		if err != nil || l == nil {
+			return
+		}
+		e.runFileCommand("cd", l.Path())
+	}, w)
+	e.setDialogLocation(d, eval.Cwd)
+	d.Show()
+	return nil
+}
+
+func (e *Editor) setDialogLocation(d *dialog.FileDialog, dir string) {
+	l, err := storage.ListerForURI(storage.NewFileURI(dir))
+	if err != nil {
+		e.debug("error opening dialog location", "dir", dir, "error", err)
+		return
+	}
+	d.SetLocation(l)
+}
+
+// runFileCommand runs the ex command cmd with paths as its escaped arguments.
+// It runs in the background, opening a file can block on a prompt.
+func (e *Editor) runFileCommand(cmd string, paths ...string) {
+	go func() {
+		err := e.Nvim.ExecLua(fileCommand, nil, cmd, paths)
+		if err != nil {
+			e.debug("error running file command", "cmd", cmd, "error", err)
+		}
+	}()
+}
@@ -0,0 +1,106 @@
+package widget
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+)
+
+// fileCommand runs an ex command with paths, escaped with fnameescape(), as
+// its arguments. If each is true it runs the command once for each path, in
+// order.
+const fileCommand = `
+local cmd, paths, each = ...
+local escaped = vim.tbl_map(vim.fn.fnameescape, paths)
+if each then
+  for _, path in ipairs(escaped) do
+    vim.cmd(cmd .. ' ' .. path)
+  end
+else
+  vim.cmd(cmd .. ' ' .. table.concat(escaped, ' '))
+end
+`
+
+var imageExtensions = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".gif":  true,
+	".svg":  true,
+}
+
+// isImage reports whether path has the extension of an image fyne can show.
+func isImage(path string) bool {
+	return imageExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// Dropped opens files dropped on the window in nvim, pass it to
+// fyne.Window.SetOnDropped. Files are opened with :drop, or with the
+// modifier held:
+//
+//	Ctrl (Cmd on macOS)  :tab drop
+//	Shift                :split
+//	Alt                  :vsplit
+//
+// An image dropped in preview mode is shown in the preview instead.
+func (e *Editor) Dropped(pos fyne.Position, uris []fyne.URI) {
+	var paths []string
+	for _, uri := range uris {
+		if uri.Scheme() != "file" {
+			e.debug("ignoring dropped uri", "uri", uri)
+			continue
+		}
+		paths = append(paths, uri.Path())
+	}
+	if len(paths) == 0 {
+		return
+	}
+
+	if e.previewMode && len(uris) == 1 && isImage(paths[0]) {
+		e.ExitPreviewMode()
+		e.showPreviewImage(uris[0])
+		return
+	}
+
+	cmd, multiple := dropCommand()
+	e.debug("opening dropped files", "cmd", cmd, "paths", paths)
+	if multiple {
+		e.runFileCommand(cmd, paths...)
+		return
+	}
+	// one request, so that the windows open in the order of the files
+	go func() {
+		err := e.Nvim.ExecLua(fileCommand, nil, cmd, paths, true)
+		if err != nil {
+			e.debug("error opening dropped files", "cmd", cmd, "error", err)
+		}
+	}()
+}
+
+// dropCommand returns the ex command to open dropped files with for the
+// modifiers held, and whether it takes several files at once.
+func dropCommand() (cmd string, multiple bool) {
+	var modifiers fyne.KeyModifier
+	if d, ok := fyne.CurrentApp().Driver().(desktop.Driver); ok {
+		modifiers = d.CurrentKeyModifiers()
+	}
+
+	tabModifier := fyne.KeyModifierControl
+	if runtime.GOOS == "darwin" {
+		tabModifier = fyne.KeyModifierSuper
+	}
+
+	switch modifiers {
+	case tabModifier:
+		return "tab drop", true
+	case fyne.KeyModifierShift:
+		return "split", false
+	case fyne.KeyModifierAlt:
+		return "vsplit", false
+	default:
+		return "drop", true
+	}
+}
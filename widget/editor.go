@@ -1,19 +1,21 @@
 package widget
 
 import (
+	"errors"
 	"fmt"
 	"image/color"
 	"math"
+	"os/exec"
+	"sync"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
-	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
 	"github.com/neovim/go-client/nvim"
-	"github.com/neovim/go-client/nvim/plugin"
 )
 
 const nvimRows = 10
@@ -41,15 +43,81 @@ type Editor struct {
 	log  logger
 	Nvim *nvim.Nvim
 
+	// CursorAnimation is how long the cursor takes to slide to a new
+	// position. Zero, the default, moves it instantly.
+	CursorAnimation time.Duration
+	// ScrollAnimation is how long scrolled lines take to slide into place.
+	// Zero, the default, scrolls instantly.
+	ScrollAnimation time.Duration
+
+	// OnTitleChanged is called when nvim sets the window title, see 'title'
+	// and 'titlestring'.
+	OnTitleChanged func(title string)
+	// OnIconChanged is called when nvim sets the icon title, see 'icon' and
+	// 'iconstring'.
+	OnIconChanged func(icon string)
+
+	// Bell selects how the editor responds to nvim's bell, see 'belloff' and
+	// 'visualbell'. It flashes by default.
+	Bell BellStyle
+	// OnBell is called for every bell, visual is set if 'visualbell' is on.
+	OnBell func(visual bool)
+
+	// BusySpinner shows a spinner in place of the cursor while nvim is busy.
+	// Otherwise the cursor is just hidden.
+	BusySpinner bool
+
+	// OnIMEChanged is called when nvim enters or leaves a mode that takes
+	// text input, so the embedder can turn the input method on or off.
+	OnIMEChanged func(enabled bool)
+
+	// OnDetach is called after Detach detaches the editor from the server.
+	OnDetach func()
+	// OnExit is called when nvim exits.
+	OnExit func()
+	// FullscreenKey toggles fullscreen, it is F11 by default and disabled if
+	// empty. See also :FynevimFullscreen.
+	FullscreenKey fyne.KeyName
+
+	// PreviewRenderers render buffers in the preview by 'filetype', buffers
+	// of other filetypes are rendered as markdown. It holds renderers for
+	// markdown, rst, asciidoc, html, json, yaml, csv and tsv, set an entry to
+	// add or replace one.
+	PreviewRenderers map[string]PreviewRenderer
+
+	// OnNewWindow is called for :FynevimNewWindow and Ctrl+Shift+N (Cmd+N on
+	// macOS). Ctrl+Shift+N goes to nvim if it isn't set.
+	OnNewWindow func()
+
 	// graphical elements
-	content         *widget.TextGrid
-	cmdline         *widget.TextGrid
-	cursor          cursor
-	markdownPreview *widget.RichText
+	content     *widget.TextGrid
+	cmdline     *widget.TextGrid
+	cursor      cursor
+	scroll      scrollAnimation
+	bellFlash   *canvas.Rectangle
+	bellAnim    *fyne.Animation
+	busySpinner *widget.Activity
+	preedit     preedit
+	preview     preview
+
+	// nvim process
+	opts          Options
+	processMu     sync.Mutex
+	closed        bool
+	exitedCleanly bool
+	stderr        *tailBuffer
+	crash         fyne.CanvasObject
 
 	// state
+	resizeMu    sync.Mutex
+	uiSize      [2]int // latest requested grid size
+	resizedTo   [2]int // grid size sent to nvim
+	resizing    bool
+	remote      bool
 	previewMode bool
 	controlHeld bool
+	busy        bool
+	imeEnabled  bool
 
 	// nvim ui-linegrid events
 	gridCursorGoto    *GridCursorGoto
@@ -65,6 +133,8 @@ type Editor struct {
 
 	// nvim ui-cmdline events
 	cmdlineShow CmdlineShow
+
+	clipboardRegister clipboardRegister
 }
 
 // Tappable interface
@@ -77,6 +147,7 @@ func (e *Editor) Tapped(pe *fyne.PointEvent) {
 // Focusable interface
 func (e *Editor) FocusGained() {
 	// e.debug("focus gained")
+	go e.claimCommands()
 }
 
 // Focusable interface
@@ -85,7 +156,20 @@ func (e *Editor) FocusLost() {
 }
 
 func (e *Editor) KeyDown(fke *fyne.KeyEvent) {
-	if e.controlHeld {
+	if isPasteShortcut(fke.Name) {
+		e.debug("paste shortcut")
+		e.Paste()
+		return
+	}
+	if e.OnNewWindow != nil && isGUIShortcut(fke.Name, fyne.KeyN) {
+		e.debug("new window shortcut")
+		// a new window sharing the server waits for nvim, which may wait for
+		// keys at a prompt that only this thread can deliver
+		go e.OnNewWindow()
+		return
+	}
+
+	if e.controlHeld && !isModifierKey(fke.Name) {
 		input := fmt.Sprintf("<C-%s>", fke.Name)
 		_, err := e.Nvim.Input(input)
 		if err != nil {
@@ -104,6 +188,17 @@ func (e *Editor) KeyDown(fke *fyne.KeyEvent) {
 	}
 }
 
+func isModifierKey(name fyne.KeyName) bool {
+	switch name {
+	case desktop.KeyShiftLeft, desktop.KeyShiftRight,
+		desktop.KeyControlLeft, desktop.KeyControlRight,
+		desktop.KeyAltLeft, desktop.KeyAltRight,
+		desktop.KeySuperLeft, desktop.KeySuperRight:
+		return true
+	}
+	return false
+}
+
 func (e *Editor) KeyUp(fke *fyne.KeyEvent) {
 	switch fke.Name {
 	case desktop.KeyControlLeft, desktop.KeyControlRight:
@@ -118,10 +213,6 @@ func (e *Editor) KeyUp(fke *fyne.KeyEvent) {
 // handle normal key input
 // Focusable interface
 func (e *Editor) TypedRune(r rune) {
-	if e.previewMode {
-		return
-	}
-
 	input := string(r)
 
 	if input == "<" {
@@ -141,6 +232,11 @@ func (e *Editor) TypedRune(r rune) {
 func (e *Editor) TypedKey(fke *fyne.KeyEvent) {
 	e.debug("received typed key input", "fke", fke)
 
+	if e.FullscreenKey != "" && fke.Name == e.FullscreenKey {
+		e.ToggleFullscreen()
+		return
+	}
+
 	var vimKeycode string
 	switch fke.Name {
 	case fyne.KeyReturn:
@@ -149,9 +245,6 @@ func (e *Editor) TypedKey(fke *fyne.KeyEvent) {
 		vimKeycode = "<BS>"
 	case fyne.KeyEscape:
 		vimKeycode = "<Esc>"
-		if e.previewMode {
-			e.ExitPreviewMode()
-		}
 	case fyne.KeyTab:
 		vimKeycode = "<Tab>"
 	default:
@@ -178,6 +271,12 @@ type cursor struct {
 	col   int
 	image *canvas.Rectangle
 	text  *canvas.Text
+
+	// animation state
+	drawn  bool
+	pos    fyne.Position
+	target fyne.Position
+	anim   *fyne.Animation
 }
 
 func (e *Editor) resizeContent(newSize fyne.Size) {
@@ -185,12 +284,40 @@ func (e *Editor) resizeContent(newSize fyne.Size) {
 	cols := int(newSize.Width / cellSize.Width)
 	rows := int(newSize.Height / cellSize.Height)
 	e.debug("resizeContent", "newSize", newSize, "cellSize", cellSize, "row", rows, "cols", cols)
-	e.Nvim.TryResizeUI(cols, rows)
+	e.resizeUI(cols, rows)
 	e.content.Resize(newSize)
 }
 
-func (e *Editor) resizeMarkdownPreview(newSize fyne.Size) {
-	e.markdownPreview.Resize(newSize)
+// resizeUI asks nvim to resize the grid without waiting for it, nvim doesn't
+// handle the request while it blocks on a prompt such as the swap file
+// ATTENTION prompt. Only the latest size is sent if several are pending.
+func (e *Editor) resizeUI(cols, rows int) {
+	e.resizeMu.Lock()
+	defer e.resizeMu.Unlock()
+	e.uiSize = [2]int{cols, rows}
+	if e.resizing || e.uiSize == e.resizedTo {
+		return
+	}
+
+	e.resizing = true
+	go func() {
+		for {
+			e.resizeMu.Lock()
+			size := e.uiSize
+			if size == e.resizedTo {
+				e.resizing = false
+				e.resizeMu.Unlock()
+				return
+			}
+			e.resizedTo = size
+			e.resizeMu.Unlock()
+
+			err := e.Nvim.TryResizeUI(size[0], size[1])
+			if err != nil {
+				e.debug("error in nvim.TryResizeUI", "error", err)
+			}
+		}
+	}()
 }
 
 func (e *Editor) resizeCmdLine(newSize fyne.Size) {
@@ -199,7 +326,60 @@ func (e *Editor) resizeCmdLine(newSize fyne.Size) {
 	e.cmdline.Resize(newSize)
 }
 
+var errNoWindow = errors.New("editor is not in a window")
+
+// requestFocus raises the editor's window and focuses the editor, e.g. when
+// fynevim -remote opens a file in it.
+func (e *Editor) requestFocus() {
+	w := e.window()
+	if w == nil {
+		return
+	}
+	w.RequestFocus()
+	w.Canvas().Focus(e)
+}
+
+// ToggleFullscreen switches the editor's window in or out of fullscreen.
+// Lua can call it with
+// vim.rpcnotify(vim.g.fynevim_channel, 'fynevim.ToggleFullscreen').
+func (e *Editor) ToggleFullscreen() {
+	w := e.window()
+	if w == nil {
+		return
+	}
+	w.SetFullScreen(!w.FullScreen())
+}
+
+func (e *Editor) fullscreenCommand() error {
+	if e.window() == nil {
+		return errNoWindow
+	}
+	e.ToggleFullscreen()
+	return nil
+}
+
+// window returns the window showing the editor, or nil before the editor is
+// shown. Another window could belong to another editor.
+func (e *Editor) window() fyne.Window {
+	c := fyne.CurrentApp().Driver().CanvasForObject(e)
+	if c == nil {
+		return nil
+	}
+	for _, w := range fyne.CurrentApp().Driver().AllWindows() {
+		if w.Canvas() == c {
+			return w
+		}
+	}
+	return nil
+}
+
 func (e *Editor) cellSize() fyne.Size {
+	return CellSize()
+}
+
+// CellSize returns the size of a cell of the editor's grid, an editor of
+// cols*width by rows*height shows a grid of cols by rows.
+func CellSize() fyne.Size {
 	size := fyne.MeasureText("M", theme.TextSize(), fyne.TextStyle{Monospace: true})
 	size.Width = float32(math.Round(float64(size.Width)))
 	size.Height = float32(math.Round(float64(size.Height)))
@@ -216,8 +396,11 @@ func (r *renderer) Layout(s fyne.Size) {
 	// cmdLineSize := fyne.NewSize(s.Width, cellSize.Height)
 	// set aside some room for the cmdline
 	// contentSize := fyne.NewSize(s.Width, s.Height-cmdLineSize.Height)
-	r.e.resizeContent(s)
-	r.e.resizeMarkdownPreview(s)
+	r.e.layoutPreview(s)
+	r.e.bellFlash.Resize(s)
+	if crash := r.e.crashOverlay(); crash != nil {
+		crash.Resize(s)
+	}
 	// r.e.resizeCmdLine(cmdLineSize)
 	// r.e.cmdline.Move(fyne.NewPos(0, contentSize.Height))
 }
@@ -230,6 +413,10 @@ func (r *renderer) MinSize() fyne.Size {
 func (r *renderer) Refresh() {
 	r.e.content.Refresh() // this is needed to redraw screen when a new line is added or removed
 	r.e.drawCursor()
+	r.e.drawPreedit()
+	if crash := r.e.crashOverlay(); crash != nil {
+		crash.Resize(r.e.Size())
+	}
 }
 
 func (r *renderer) Objects() []fyne.CanvasObject {
@@ -237,12 +424,21 @@ func (r *renderer) Objects() []fyne.CanvasObject {
 		// r.e.cmdline,
 	}
 
+	o = append(o, r.e.content)
+	o = append(o, r.e.scroll.clip)
+	o = append(o, r.e.cursor.image)
+	o = append(o, r.e.cursor.text)
+	o = append(o, r.e.busySpinner)
+	o = append(o, r.e.preedit.background)
+	o = append(o, r.e.preedit.label)
 	if r.e.previewMode {
-		o = append(o, r.e.markdownPreview)
-	} else {
-		o = append(o, r.e.content)
-		o = append(o, r.e.cursor.image)
-		o = append(o, r.e.cursor.text)
+		o = append(o, r.e.preview.background)
+		o = append(o, r.e.preview.handle)
+		o = append(o, r.e.preview.pane)
+	}
+	o = append(o, r.e.bellFlash)
+	if crash := r.e.crashOverlay(); crash != nil {
+		o = append(o, crash)
 	}
 
 	return o
@@ -261,6 +457,19 @@ func (e *Editor) CreateRenderer() fyne.WidgetRenderer {
 }
 
 func (e *Editor) drawCursor() {
+	if e.busy {
+		e.cursor.image.Hide()
+		e.cursor.text.Hide()
+		e.showBusySpinner()
+		return
+	}
+	e.hideBusySpinner()
+	e.cursor.image.Show()
+
+	if e.currentMode.ModeIdx >= len(e.modeInfoSet.modeInfo) || e.cursor.row >= len(e.content.Rows) ||
+		e.cursor.col >= len(e.content.Rows[e.cursor.row].Cells) {
+		return // nothing drawn yet, nvim may still be starting up
+	}
 	modeInfo := e.modeInfoSet.modeInfo[e.currentMode.ModeIdx]
 	row := e.content.Row(e.cursor.row)
 	cell := row.Cells[e.cursor.col]
@@ -288,7 +497,6 @@ func (e *Editor) drawCursor() {
 	case "block":
 		cursorSize = cellSize
 		text = string(cell.Rune)
-		e.cursor.text.Move(cursorPos)
 		e.cursor.text.Color = fgColor
 		e.cursor.text.Text = text
 		e.cursor.text.Resize(cellSize)
@@ -304,55 +512,176 @@ func (e *Editor) drawCursor() {
 		panic(fmt.Sprintf("unexpected cursor %v", modeInfo.CursorShape))
 	}
 	e.cursor.image.Resize(cursorSize)
-	e.cursor.image.Move(cursorPos)
+	e.moveCursor(cursorPos)
 	e.cursor.image.FillColor = bgColor
 	e.cursor.image.Refresh()
 	e.cursor.text.Refresh()
 }
 
-func NewEditor(log logger, nvimProcessOptions []nvim.ChildProcessOption) *Editor {
+func newEditor(log logger) *Editor {
 	e := &Editor{
-		log:             log,
-		content:         widget.NewTextGrid(),
-		cmdline:         widget.NewTextGrid(),
-		markdownPreview: widget.NewRichText(),
+		log:           log,
+		content:       widget.NewTextGrid(),
+		cmdline:       widget.NewTextGrid(),
+		scroll:        newScrollAnimation(),
+		bellFlash:     canvas.NewRectangle(color.Transparent),
+		busySpinner:   widget.NewActivity(),
+		preedit:       newPreedit(),
+		FullscreenKey: fyne.KeyF11,
 	}
+	e.preview = newPreview(e)
+	e.PreviewRenderers = defaultPreviewRenderers()
 	e.ExtendBaseWidget(e)
 	e.content.ShowLineNumbers = false
 	e.content.ShowWhitespace = false
-	e.markdownPreview.Scroll = container.ScrollVerticalOnly
-	e.markdownPreview.Wrapping = fyne.TextWrapWord
+	e.bellFlash.Hide()
+	e.busySpinner.Hide()
 
 	if e.log == nil {
 		e.log = noopLogger{}
 	}
 
-	e.debug("starting nvim child process")
-	var err error
-	e.Nvim, err = nvim.NewChildProcess(nvimProcessOptions...)
+	return e
+}
+
+// Options configures an editor created with NewEditorWithOptions.
+type Options struct {
+	// Log receives the editor's log output, nothing is logged if it is nil.
+	Log logger
+
+	// ChildProcessOptions start nvim as a child process, they must include
+	// the --embed argument.
+	ChildProcessOptions []nvim.ChildProcessOption
+
+	// ServerAddress attaches to a running nvim server instead of starting
+	// one, see nvim --listen. It is a Unix socket path or host:port.
+	ServerAddress string
+	DialOptions   []nvim.DialOption
+
+	// Command returns the command to start nvim as a child process, it must
+	// include the --embed argument. It is used instead of ChildProcessOptions
+	// so that the editor can show nvim's stderr if it crashes, and is called
+	// again to restart nvim.
+	Command func() *exec.Cmd
+
+	// Cols and Rows are the grid size nvim starts with, see CellSize. The
+	// grid follows the editor's size once it is laid out.
+	Cols, Rows int
+}
+
+// NewEditorWithOptions returns an editor attached to a new nvim child process
+// or to a running server. An error wrapping exec.ErrNotFound is returned if
+// the nvim executable can't be found.
+func NewEditorWithOptions(opts Options) (*Editor, error) {
+	e := newEditor(opts.Log)
+	e.opts = opts
+
+	err := e.start()
+	if err != nil {
+		return nil, err
+	}
+
+	clientType := nvim.EmbedderClientType
+	if e.remote {
+		clientType = nvim.UIClientType
+	}
+	err = e.attach(clientType)
+	if err != nil {
+		e.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+// NewEditor returns an editor for a new nvim child process, which is started
+// with nvimProcessOptions and must be given --embed. It panics if nvim can't
+// be started, see NewEditorWithOptions.
+func NewEditor(log logger, nvimProcessOptions []nvim.ChildProcessOption) *Editor {
+	e, err := NewEditorWithOptions(Options{Log: log, ChildProcessOptions: nvimProcessOptions})
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// NewRemoteEditor returns an editor attached to a running nvim server, see
+// nvim --listen. The address is a Unix socket path or host:port. It panics if
+// the server can't be reached, see NewEditorWithOptions.
+func NewRemoteEditor(log logger, address string, dialOptions ...nvim.DialOption) *Editor {
+	e, err := NewEditorWithOptions(Options{Log: log, ServerAddress: address, DialOptions: dialOptions})
 	if err != nil {
 		panic(err)
 	}
+	return e
+}
 
-	err = e.Nvim.SetClientInfo(
+// attach registers the editor's handlers and commands with e.Nvim and
+// attaches it as a UI.
+func (e *Editor) attach(clientType nvim.ClientType) error {
+	err := e.Nvim.SetClientInfo(
 		"fynevim",
 		nvim.ClientVersion{Major: 0, Minor: 0, Patch: 0},
-		nvim.EmbedderClientType,
+		clientType,
 		map[string]*nvim.ClientMethod{},
 		nvim.ClientAttributes{},
 	)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("Error setting client info: %w", err)
 	}
 
 	e.hlTable = HightlightTable{}
 
 	// handle redraw events from nvim
 	e.info("registering redraw handler")
-	e.Nvim.RegisterHandler("redraw", e.handleNvimEvents)
+	err = e.Nvim.RegisterHandler("redraw", e.handleNvimEvents)
+	if err != nil {
+		return fmt.Errorf("Error registering redraw handler: %w", err)
+	}
+
+	e.info("registering clipboard provider")
+	err = e.registerClipboardProvider()
+	if err != nil {
+		return fmt.Errorf("Error registering clipboard provider: %w", err)
+	}
+
+	e.info("registering commands")
+	err = e.registerCommands()
+	if err != nil {
+		return fmt.Errorf("Error registering commands: %w", err)
+	}
+
+	err = e.Nvim.RegisterHandler("fynevim.RequestFocus", e.requestFocus)
+	if err == nil {
+		err = e.Nvim.RegisterHandler("fynevim.ToggleFullscreen", e.ToggleFullscreen)
+	}
+	if err != nil {
+		return fmt.Errorf("Error registering window handlers: %w", err)
+	}
+
+	e.info("registering preview handlers")
+	err = e.registerPreviewHandlers()
+	if err != nil {
+		return fmt.Errorf("Error registering preview handlers: %w", err)
+	}
+
+	e.info("registering exit handler")
+	err = e.Nvim.RegisterHandler("fynevim.VimLeave", e.exited)
+	if err == nil {
+		err = e.Nvim.ExecLua(exitAutocmd, nil, e.Nvim.ChannelID())
+	}
+	if err != nil {
+		return fmt.Errorf("Error registering exit handler: %w", err)
+	}
 
-	e.info("attaching ui to nvim")
-	err = e.Nvim.AttachUI(nvimCols, nvimRows, map[string]any{
+	// everything else is set up first, an embedded nvim sources the user's
+	// config and opens files once the ui is attached and may block on a
+	// prompt that only the ui can answer
+	cols, rows := e.opts.Cols, e.opts.Rows
+	if cols <= 0 || rows <= 0 {
+		cols, rows = nvimCols, nvimRows
+	}
+	e.info("attaching ui to nvim", "cols", cols, "rows", rows)
+	err = e.Nvim.AttachUI(cols, rows, map[string]any{
 		"ext_linegrid": true,
 		"ext_hlstate":  true,
 		// "ext_cmdline":  true,
@@ -362,40 +691,104 @@ func NewEditor(log logger, nvimProcessOptions []nvim.ChildProcessOption) *Editor
 		// "ext_termcolors":  true,
 	})
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("Error attaching ui: %w", err)
 	}
+	return nil
+}
 
-	// register preview command
-	plug := plugin.New(e.Nvim)
-	plug.HandleCommand(&plugin.CommandOptions{Name: "Preview", NArgs: "0"}, e.EnterPreviewMode)
-	plug.RegisterForTests() // TODO this works but is this how these should be registered?
+// exitAutocmd notifies the editor when nvim exits. The group is per channel
+// so that several editors can share a server.
+const exitAutocmd = `
+local chan = ...
+vim.api.nvim_create_autocmd('VimLeave', {
+  group = vim.api.nvim_create_augroup('fynevim_' .. chan, {}),
+  callback = function()
+    pcall(vim.rpcnotify, chan, 'fynevim.VimLeave')
+  end,
+})
+`
+
+func (e *Editor) exited() {
+	e.processMu.Lock()
+	if e.exitedCleanly {
+		e.processMu.Unlock()
+		return
+	}
+	e.exitedCleanly = true
+	e.processMu.Unlock()
 
-	return e
+	e.info("nvim exited")
+	if e.OnExit != nil {
+		e.OnExit()
+	}
 }
 
-// EnterPreviewMode Renders the current buffer as markdown richtext
-func (e *Editor) EnterPreviewMode() error {
-	e.previewMode = true
-	buf, err := e.Nvim.CurrentBuffer()
+var errNotRemote = errors.New("not attached to a server, an embedded nvim exits when detached")
+
+// Detach detaches the editor from the nvim server it was attached to with
+// NewRemoteEditor, leaving the server running to reattach to later. The
+// connection stays open for the caller to close.
+func (e *Editor) Detach() error {
+	if !e.remote {
+		return errNotRemote
+	}
+
+	e.info("detaching ui from nvim")
+	err := e.Nvim.ExecLua("pcall(vim.api.nvim_del_augroup_by_name, 'fynevim_' .. ...)", nil, e.Nvim.ChannelID())
 	if err != nil {
-		return fmt.Errorf("Error getting buffer: %v", err)
+		return err
 	}
-	bufLines, err := e.Nvim.BufferLines(buf, 0, -1, false)
+	err = e.Nvim.DetachUI()
 	if err != nil {
-		return fmt.Errorf("Error reading buffer lines: %v", err)
+		return err
 	}
-	var contents string
-	for _, line := range bufLines {
-		contents += string(line) + "\n"
+	if e.OnDetach != nil {
+		e.OnDetach()
 	}
+	return nil
+}
+
+// detachCommand handles :FynevimDetach, nvim waits for the command to return
+// so the UI is detached afterwards.
+func (e *Editor) detachCommand() error {
+	if !e.remote {
+		return errNotRemote
+	}
+	go func() {
+		if err := e.Detach(); err != nil {
+			e.debug("error detaching", "error", err)
+		}
+	}()
+	return nil
+}
+
+var errNoNewWindow = errors.New("new windows aren't supported here")
 
-	e.markdownPreview.ParseMarkdown(contents)
+func (e *Editor) newWindowCommand() error {
+	if e.OnNewWindow == nil {
+		return errNoNewWindow
+	}
+	// nvim waits for the command to return, which a new window sharing the
+	// server would wait for in turn
+	go e.OnNewWindow()
 	return nil
 }
 
-func (e *Editor) ExitPreviewMode() {
-	e.previewMode = false
-	e.markdownPreview.ParseMarkdown("")
+// showBusySpinner places the busy spinner over the cursor's cell, if enabled.
+func (e *Editor) showBusySpinner() {
+	if !e.BusySpinner {
+		return
+	}
+	cellSize := e.cellSize()
+	e.busySpinner.Move(fyne.NewPos(cellSize.Width*float32(e.cursor.col), cellSize.Height*float32(e.cursor.row)))
+	e.busySpinner.Resize(fyne.NewSquareSize(cellSize.Height))
+	e.busySpinner.Show()
+	e.busySpinner.Start()
+}
+
+func (e *Editor) hideBusySpinner() {
+	e.busySpinner.Stop()
+	e.busySpinner.Hide()
 }
 
 func (e *Editor) clearRow(row int) {
@@ -413,12 +806,11 @@ func (e *Editor) handleNvimEvents(updates ...[]any) {
 
 		switch eventName {
 		case "mode_info_set":
-			// modeInfoSet = []ModeInfo{}
-
 			for _, d := range eventData {
 				data := d.([]any)
 				e.modeInfoSet.cursorStyleEnabled = data[0].(bool)
 				modeInfoList := data[1].([]any)
+				e.modeInfoSet.modeInfo = nil
 
 				for _, mi := range modeInfoList {
 					modeInfoMap := mi.(map[string]any)
@@ -448,6 +840,7 @@ func (e *Editor) handleNvimEvents(updates ...[]any) {
 				e.currentMode.Mode = data[0].(string)
 				e.currentMode.ModeIdx = toi(data[1])
 			}
+			e.updateIME()
 
 		case "default_colors_set":
 			for _, d := range eventData {
@@ -549,6 +942,7 @@ func (e *Editor) handleNvimEvents(updates ...[]any) {
 				Curline: toi(ed[4]),
 				Curcol:  toi(ed[5]),
 			}
+			e.followPreview(e.winViewport)
 
 		case "grid_scroll":
 			ed := eventData[0].([]any)
@@ -574,6 +968,7 @@ func (e *Editor) handleNvimEvents(updates ...[]any) {
 
 			// grid resize
 			if e.gridResize != nil {
+				e.stopScrollAnimation()
 				rows := e.gridResize.Height
 				cols := e.gridResize.Width
 				// add extra rows to the bottom of the grid if needed
@@ -601,6 +996,10 @@ func (e *Editor) handleNvimEvents(updates ...[]any) {
 			}
 
 			// grid scrolls
+			scrolled := len(e.gridScrollUpdates) > 0
+			if scrolled {
+				e.prepareScrollAnimation(e.gridScrollUpdates)
+			}
 			for _, gridScroll := range e.gridScrollUpdates {
 				e.debug("handling grid scroll", "rows", gridScroll.Rows)
 
@@ -641,6 +1040,12 @@ func (e *Editor) handleNvimEvents(updates ...[]any) {
 			}
 			e.gridLineUpdates = nil
 
+			if scrolled {
+				e.startScrollAnimation()
+			} else {
+				e.updateScrollAnimation()
+			}
+
 			// update cursor position
 			if e.gridCursorGoto != nil {
 				e.cursor.row = e.gridCursorGoto.Row
@@ -651,6 +1056,38 @@ func (e *Editor) handleNvimEvents(updates ...[]any) {
 			e.debug("refreshing editor")
 			e.Refresh()
 
+		case "set_title":
+			for _, d := range eventData {
+				data := d.([]any)
+				title := data[0].(string)
+				e.debug("set_title", "title", title)
+				if e.OnTitleChanged != nil {
+					e.OnTitleChanged(title)
+				}
+			}
+
+		case "set_icon":
+			for _, d := range eventData {
+				data := d.([]any)
+				icon := data[0].(string)
+				e.debug("set_icon", "icon", icon)
+				if e.OnIconChanged != nil {
+					e.OnIconChanged(icon)
+				}
+			}
+
+		case "busy_start":
+			e.busy = true
+
+		case "busy_stop":
+			e.busy = false
+
+		case "bell":
+			e.ring(false)
+
+		case "visual_bell":
+			e.ring(true)
+
 		case "cmdline_show":
 			for _, ed := range eventData {
 				e.cmdlineShow = NewCmlineShow(ed)
@@ -0,0 +1,120 @@
+package widget
+
+import (
+	"net/url"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// zoom limits of the image preview, 1 fits the image to the preview
+const (
+	maxImageZoom  = 8
+	imageZoomStep = 1.25
+)
+
+// imagePreview shows an image file in the preview, with a toolbar to zoom in
+// and out or fit the image to the preview.
+type imagePreview struct {
+	image  *canvas.Image
+	scroll *container.Scroll
+	view   fyne.CanvasObject
+	zoom   float32
+}
+
+func newImagePreview() *imagePreview {
+	v := &imagePreview{image: &canvas.Image{FillMode: canvas.ImageFillContain}, zoom: 1}
+	v.scroll = container.NewScroll(v.image)
+	toolbar := widget.NewToolbar(
+		widget.NewToolbarAction(theme.ZoomInIcon(), func() { v.setZoom(v.zoom * imageZoomStep) }),
+		widget.NewToolbarAction(theme.ZoomOutIcon(), func() { v.setZoom(v.zoom / imageZoomStep) }),
+		widget.NewToolbarAction(theme.ZoomFitIcon(), func() { v.setZoom(1) }),
+	)
+	v.view = container.NewBorder(toolbar, nil, nil, nil, v.scroll)
+	return v
+}
+
+// show loads the image at uri, fitted to the preview.
+func (v *imagePreview) show(uri fyne.URI) {
+	v.image.File = ""
+	v.image.Resource = nil
+	if uri.Scheme() == "file" {
+		v.image.File = uri.Path()
+	} else if res, err := storage.LoadResourceFromURI(uri); err == nil {
+		v.image.Resource = res
+	}
+	v.setZoom(1)
+}
+
+// setZoom scales the image to zoom times the size that fits the preview.
+func (v *imagePreview) setZoom(zoom float32) {
+	v.zoom = min(max(zoom, 1), maxImageZoom)
+	if v.zoom == 1 {
+		v.image.SetMinSize(fyne.Size{})
+	} else {
+		size := v.scroll.Size()
+		v.image.SetMinSize(fyne.NewSize(size.Width*v.zoom, size.Height*v.zoom))
+	}
+	v.image.Refresh()
+	v.scroll.Refresh()
+}
+
+// resolveImages makes the paths of local images in the segments parsed from
+// the markdown source relative to dir. RichText.ParseMarkdown makes them
+// relative to the working directory of the process.
+func resolveImages(source string, segments []widget.RichTextSegment, dir string) {
+	resolved := map[string]string{}
+	doc := goldmark.DefaultParser().Parse(text.NewReader([]byte(source)))
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		img, ok := n.(*ast.Image)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		dest := string(img.Destination)
+		if u, err := url.Parse(dest); err == nil && u.Scheme != "" || filepath.IsAbs(dest) {
+			return ast.WalkContinue, nil
+		}
+		if abs, err := filepath.Abs(dest); err == nil {
+			resolved[filepath.ToSlash(abs)] = filepath.Join(dir, dest)
+		}
+		return ast.WalkContinue, nil
+	})
+	if len(resolved) > 0 {
+		replaceImages(segments, resolved)
+	}
+}
+
+func replaceImages(segments []widget.RichTextSegment, resolved map[string]string) {
+	walkSegments(segments, func(s widget.RichTextSegment) {
+		img, ok := s.(*widget.ImageSegment)
+		if !ok || img.Source == nil || img.Source.Scheme() != "file" {
+			return
+		}
+		if path, ok := resolved[filepath.ToSlash(img.Source.Path())]; ok {
+			img.Source = storage.NewFileURI(path)
+		}
+	})
+}
+
+// walkSegments calls fn for the segments and those nested in paragraphs and
+// lists.
+func walkSegments(segments []widget.RichTextSegment, fn func(widget.RichTextSegment)) {
+	for _, s := range segments {
+		fn(s)
+		switch s := s.(type) {
+		case *widget.ParagraphSegment:
+			walkSegments(s.Texts, fn)
+		case *widget.ListSegment:
+			walkSegments(s.Items, fn)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package widget
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+)
+
+// preedit is the input method's composition in progress, drawn over the
+// cells at the cursor until it is committed.
+type preedit struct {
+	text       string
+	background *canvas.Rectangle
+	label      *canvas.Text
+}
+
+func newPreedit() preedit {
+	p := preedit{
+		background: canvas.NewRectangle(theme.BackgroundColor()),
+		label:      canvas.NewText("", theme.ForegroundColor()),
+	}
+	p.label.TextStyle = fyne.TextStyle{Monospace: true, Underline: true}
+	p.background.Hide()
+	p.label.Hide()
+	return p
+}
+
+// imeModes are the modes that take text input, the input method is turned
+// off in all others so that normal mode commands aren't composed.
+var imeModes = map[string]bool{
+	"insert":          true,
+	"replace":         true,
+	"cmdline_normal":  true,
+	"cmdline_insert":  true,
+	"cmdline_replace": true,
+}
+
+// IMEEnabled reports whether nvim is in a mode that takes text input, where
+// the input method should be on.
+func (e *Editor) IMEEnabled() bool {
+	return e.imeEnabled
+}
+
+// SetPreedit shows text, the input method's composition in progress, inline
+// at the cursor. An empty text hides it. Fyne has no composition events yet,
+// so this is for platform code that talks to the input method directly. It
+// is ignored while IMEEnabled is false.
+func (e *Editor) SetPreedit(text string) {
+	if !e.imeEnabled {
+		text = ""
+	}
+	e.preedit.text = text
+	e.drawPreedit()
+}
+
+// CommitPreedit sends text composed by the input method to nvim and clears
+// the preedit text. Committed text that arrives through TypedRune doesn't
+// need this.
+func (e *Editor) CommitPreedit(text string) {
+	e.SetPreedit("")
+	if text == "" {
+		return
+	}
+
+	e.debug("committing preedit", "text", text)
+	_, err := e.Nvim.Input(strings.ReplaceAll(text, "<", "<LT>"))
+	if err != nil {
+		e.debug("error in nvim.Input", "error", err)
+	}
+}
+
+// updateIME turns the input method on or off to follow the current mode.
+func (e *Editor) updateIME() {
+	enabled := imeModes[e.currentMode.Mode]
+	if enabled == e.imeEnabled {
+		return
+	}
+
+	e.debug("ime", "enabled", enabled, "mode", e.currentMode.Mode)
+	e.imeEnabled = enabled
+	if !enabled {
+		e.SetPreedit("")
+	}
+	if e.OnIMEChanged != nil {
+		e.OnIMEChanged(enabled)
+	}
+}
+
+func (e *Editor) drawPreedit() {
+	p := &e.preedit
+	if p.text == "" {
+		p.background.Hide()
+		p.label.Hide()
+		return
+	}
+
+	cellSize := e.cellSize()
+	pos := fyne.NewPos(cellSize.Width*float32(e.cursor.col), cellSize.Height*float32(e.cursor.row))
+	size := fyne.MeasureText(p.text, theme.TextSize(), p.label.TextStyle)
+	size.Height = cellSize.Height
+
+	if attr, ok := e.hlTable[0]; ok {
+		p.background.FillColor = attr.Background
+		p.label.Color = attr.Foreground
+	}
+	p.background.Move(pos)
+	p.background.Resize(size)
+	p.label.Text = p.text
+	p.label.Move(pos)
+	p.label.Resize(size)
+	p.background.Show()
+	p.label.Show()
+	p.background.Refresh()
+	p.label.Refresh()
+}
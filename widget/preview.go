@@ -0,0 +1,392 @@
+package widget
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/neovim/go-client/nvim"
+)
+
+// previewDebounce is how long the preview waits for more changes to the
+// buffer before rendering it again.
+const previewDebounce = 150 * time.Millisecond
+
+// minPreviewSplit limits how far the splitter can be dragged to either side.
+const minPreviewSplit = 0.2
+
+// preview renders a buffer for :Preview, next to the grid. It is attached to
+// the buffer with nvim_buf_attach and keeps a copy of its lines, which is
+// rendered again after the buffer stops changing for previewDebounce.
+type preview struct {
+	empty      *widget.RichText
+	scroll     *container.Scroll // holds the rendered buffer
+	outline    *widget.Button
+	outlineBar *fyne.Container // hidden if the document has no headings
+	headings   []previewHeading
+	text       fyne.CanvasObject // the outline above the rendered buffer
+	image      *imagePreview
+	body       *fyne.Container // shows the text or the image
+	pane       *previewPane
+	background *canvas.Rectangle // hides grid cells that nvim didn't resize yet
+	handle     *splitHandle
+	split      float32 // fraction of the width taken by the grid
+	fallback   PreviewRenderer
+
+	mu        sync.Mutex
+	buf       nvim.Buffer
+	active    bool
+	doc       PreviewDocument
+	timer     *time.Timer
+	detaching map[nvim.Buffer]int // detach events to ignore, see detachPreview
+	detaches  sync.WaitGroup      // detaches still running, see ExitPreviewMode
+
+	win     nvim.Window // window the preview follows
+	curline int
+}
+
+func newPreview(e *Editor) preview {
+	empty := widget.NewRichText()
+	// the preview scrolls the rendered buffer itself, so that the position is
+	// kept when it is rendered again
+	scroll := container.NewVScroll(empty)
+	outline := widget.NewButtonWithIcon("Outline", theme.ListIcon(), e.showOutline)
+	outline.Importance = widget.LowImportance
+	outlineBar := container.NewHBox(outline)
+	outlineBar.Hide()
+	text := container.NewBorder(outlineBar, nil, nil, nil, scroll)
+	body := container.NewStack(text)
+	return preview{
+		empty:      empty,
+		scroll:     scroll,
+		outline:    outline,
+		outlineBar: outlineBar,
+		text:       text,
+		image:      newImagePreview(),
+		body:       body,
+		pane:       newPreviewPane(e, body),
+		background: canvas.NewRectangle(theme.Color(theme.ColorNameBackground)),
+		handle:     newSplitHandle(e),
+		split:      0.5,
+	}
+}
+
+func (e *Editor) registerPreviewHandlers() error {
+	err := e.Nvim.RegisterHandler(nvim.EventBufLines, e.previewLinesChanged)
+	if err != nil {
+		return err
+	}
+	return e.Nvim.RegisterHandler(nvim.EventBufDetach, e.previewDetached)
+}
+
+// togglePreview runs :Preview, which opens the preview or closes it again.
+func (e *Editor) togglePreview() error {
+	if e.previewMode {
+		e.ExitPreviewMode()
+		return nil
+	}
+	return e.EnterPreviewMode()
+}
+
+// EnterPreviewMode renders the current buffer next to the grid with the
+// renderer for its filetype, and renders it again as the buffer changes. The
+// preview scrolls along with the cursor in the current window. Image files
+// are shown as images.
+func (e *Editor) EnterPreviewMode() error {
+	buf, err := e.Nvim.CurrentBuffer()
+	if err != nil {
+		return fmt.Errorf("Error getting buffer: %v", err)
+	}
+	win, err := e.Nvim.CurrentWindow()
+	if err != nil {
+		return fmt.Errorf("Error getting window: %v", err)
+	}
+	name, err := e.Nvim.BufferName(buf)
+	if err != nil {
+		return fmt.Errorf("Error getting buffer name: %v", err)
+	}
+	var filetype string
+	err = e.Nvim.Call("getbufvar", &filetype, buf, "&filetype")
+	if err != nil {
+		return fmt.Errorf("Error getting filetype: %v", err)
+	}
+	if e.previewMode {
+		e.previewMode = false
+		if old, active := e.resetPreview(); active {
+			e.detachPreview(old)
+		}
+	}
+
+	if name != "" && isImage(name) {
+		e.showPreviewImage(storage.NewFileURI(name))
+		return nil
+	}
+
+	p := &e.preview
+	p.mu.Lock()
+	p.buf = buf
+	p.active = true
+	p.doc = PreviewDocument{Filetype: filetype, Path: name}
+	p.win = win
+	p.curline = -1
+	p.mu.Unlock()
+
+	// a detach still running could detach the buffer again after attaching
+	p.detaches.Wait()
+	// the first lines event holds the whole buffer
+	attached, err := e.Nvim.AttachBuffer(buf, true, map[string]any{})
+	if err == nil && !attached {
+		err = fmt.Errorf("buffer %v is not loaded", buf)
+	}
+	if err != nil {
+		e.resetPreview()
+		return fmt.Errorf("Error attaching to buffer: %v", err)
+	}
+
+	e.previewMode = true
+	e.layoutPreview(e.Size())
+	e.Refresh()
+	return nil
+}
+
+func (e *Editor) ExitPreviewMode() {
+	e.previewMode = false
+	buf, active := e.resetPreview()
+	if active {
+		e.preview.detaches.Add(1)
+		go func() {
+			defer e.preview.detaches.Done()
+			e.detachPreview(buf)
+		}()
+	}
+	e.layoutPreview(e.Size())
+	e.Refresh()
+}
+
+// detachPreview stops the updates of buf. nvim confirms with a detach event,
+// which may only be handled after the preview attached again, so it is
+// ignored.
+func (e *Editor) detachPreview(buf nvim.Buffer) {
+	p := &e.preview
+	p.mu.Lock()
+	if p.detaching == nil {
+		p.detaching = map[nvim.Buffer]int{}
+	}
+	p.detaching[buf]++
+	p.mu.Unlock()
+
+	_, err := e.Nvim.DetachBuffer(buf)
+	if err != nil {
+		e.debug("error detaching from buffer", "error", err)
+		p.mu.Lock()
+		p.detaching[buf]--
+		p.mu.Unlock()
+	}
+}
+
+// resetPreview forgets the previewed buffer and returns it, active is false
+// if there was none.
+func (e *Editor) resetPreview() (buf nvim.Buffer, active bool) {
+	p := &e.preview
+	p.mu.Lock()
+	buf, active = p.buf, p.active
+	p.active = false
+	p.doc = PreviewDocument{}
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	p.mu.Unlock()
+
+	p.scroll.Content = p.empty
+	p.scroll.ScrollToTop()
+	p.headings = nil
+	p.outlineBar.Hide()
+	p.body.Objects = []fyne.CanvasObject{p.text}
+	p.body.Refresh()
+	return buf, active
+}
+
+// previewLinesChanged applies a nvim_buf_lines_event to the preview's copy of
+// the buffer. The lines from first up to last were replaced by data, a last
+// of -1 replaces the whole buffer.
+func (e *Editor) previewLinesChanged(buf nvim.Buffer, changedtick any, first, last int, data []string, more bool) {
+	p := &e.preview
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.active || buf != p.buf {
+		return
+	}
+
+	whole := last < 0
+	if whole {
+		p.doc.Lines = data
+	} else {
+		first = min(first, len(p.doc.Lines))
+		last = min(max(last, first), len(p.doc.Lines))
+		p.doc.Lines = slices.Concat(p.doc.Lines[:first], data, p.doc.Lines[last:])
+	}
+	if more {
+		return
+	}
+
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	if whole {
+		// rendered right away when the preview opens or the file is reloaded
+		p.timer = time.AfterFunc(0, e.renderPreview)
+		return
+	}
+	p.timer = time.AfterFunc(previewDebounce, e.renderPreview)
+}
+
+// previewDetached closes the preview when nvim stops sending changes to the
+// buffer, e.g. because it was unloaded.
+func (e *Editor) previewDetached(buf nvim.Buffer) {
+	p := &e.preview
+	p.mu.Lock()
+	if p.detaching[buf] > 0 {
+		p.detaching[buf]--
+		p.mu.Unlock()
+		return
+	}
+	current := p.active && buf == p.buf
+	p.mu.Unlock()
+	if current {
+		e.debug("previewed buffer detached", "buffer", buf)
+		e.previewMode = false
+		e.resetPreview()
+		e.layoutPreview(e.Size())
+		e.Refresh()
+	}
+}
+
+// renderPreview renders the preview's copy of the buffer with the renderer
+// for its filetype. The scroll position is kept, as far as the new preview is
+// long enough.
+func (e *Editor) renderPreview() {
+	p := &e.preview
+	p.mu.Lock()
+	if !p.active {
+		p.mu.Unlock()
+		return
+	}
+	doc := p.doc
+	p.mu.Unlock()
+
+	doc.OpenLink = func(u *url.URL) { e.openPreviewLink(u, doc.Path) }
+	r := e.previewRenderer(doc.Filetype)
+	p.scroll.Content = r.Render(doc)
+	p.scroll.Refresh()
+	e.updateOutline(r)
+}
+
+// showPreviewImage opens the preview with the image at uri.
+func (e *Editor) showPreviewImage(uri fyne.URI) {
+	p := &e.preview
+	p.body.Objects = []fyne.CanvasObject{p.image.view}
+	p.body.Refresh()
+	e.previewMode = true
+	e.layoutPreview(e.Size())
+	e.Refresh()
+	p.image.show(uri)
+}
+
+// followPreview scrolls the preview to the same part of the document as the
+// cursor line of the window it follows. Markdown doesn't map lines to
+// positions in the rendered text, so the preview is scrolled by the
+// fraction of the buffer above the cursor.
+func (e *Editor) followPreview(viewport WinViewport) {
+	p := &e.preview
+	p.mu.Lock()
+	follow := p.active && viewport.Win == p.win && viewport.Curline != p.curline
+	p.curline = viewport.Curline
+	lines := len(p.doc.Lines)
+	p.mu.Unlock()
+	if !follow || lines <= 1 {
+		return
+	}
+
+	hidden := p.scroll.Content.MinSize().Height - p.scroll.Size().Height
+	if hidden <= 0 {
+		return
+	}
+	fraction := float32(min(viewport.Curline, lines-1)) / float32(lines-1)
+	p.scroll.Offset.Y = fraction * hidden
+	p.scroll.Refresh()
+}
+
+// layoutPreview places the grid and, in preview mode, the splitter and the
+// preview next to it in an editor of size.
+func (e *Editor) layoutPreview(size fyne.Size) {
+	if !e.previewMode {
+		e.resizeContent(size)
+		return
+	}
+
+	p := &e.preview
+	handleWidth := p.handle.MinSize().Width
+	gridWidth := float32(int(size.Width * p.split))
+	e.resizeContent(fyne.NewSize(gridWidth, size.Height))
+	p.handle.Move(fyne.NewPos(gridWidth, 0))
+	p.handle.Resize(fyne.NewSize(handleWidth, size.Height))
+	pos := fyne.NewPos(gridWidth+handleWidth, 0)
+	previewSize := fyne.NewSize(size.Width-gridWidth-handleWidth, size.Height)
+	p.background.Move(pos)
+	p.background.Resize(previewSize)
+	p.pane.Move(pos)
+	p.pane.Resize(previewSize)
+}
+
+// splitHandle is the splitter between the grid and the preview, dragging it
+// resizes both.
+type splitHandle struct {
+	widget.BaseWidget
+	e *Editor
+}
+
+func newSplitHandle(e *Editor) *splitHandle {
+	h := &splitHandle{e: e}
+	h.ExtendBaseWidget(h)
+	return h
+}
+
+func (h *splitHandle) CreateRenderer() fyne.WidgetRenderer {
+	line := canvas.NewRectangle(theme.Color(theme.ColorNameSeparator))
+	return widget.NewSimpleRenderer(line)
+}
+
+func (h *splitHandle) MinSize() fyne.Size {
+	return fyne.NewSize(theme.Padding(), 0)
+}
+
+// Cursor implements desktop.Cursorable.
+func (h *splitHandle) Cursor() desktop.Cursor {
+	return desktop.HResizeCursor
+}
+
+// Dragged implements fyne.Draggable.
+func (h *splitHandle) Dragged(ev *fyne.DragEvent) {
+	e := h.e
+	size := e.Size()
+	if size.Width <= 0 {
+		return
+	}
+	split := e.preview.split + ev.Dragged.DX/size.Width
+	e.preview.split = min(max(split, minPreviewSplit), 1-minPreviewSplit)
+	e.layoutPreview(size)
+}
+
+// DragEnd implements fyne.Draggable.
+func (h *splitHandle) DragEnd() {}
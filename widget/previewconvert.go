@@ -0,0 +1,412 @@
+package widget
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// The converters below turn the common parts of other markup into markdown
+// for the preview. Anything they don't know is passed through as text.
+
+var (
+	rstLink       = regexp.MustCompile("`([^`<]+?)\\s*<([^>]+)>`__?")
+	rstLiteral    = regexp.MustCompile("``([^`]+)``")
+	rstRole       = regexp.MustCompile(":[a-z]+:`([^`]+)`")
+	rstDirective  = regexp.MustCompile(`^\.\.\s+([a-z-]+)::\s*(.*)$`)
+	rstListMarker = regexp.MustCompile(`^\s*([-*+]|\d+[.)]|#\.)\s`)
+)
+
+// rstToMarkdown converts reStructuredText sections, literal blocks, code,
+// image and note directives, links and literals to markdown.
+func rstToMarkdown(source string) string {
+	lines := strings.Split(source, "\n")
+	var out []string
+	levels := map[string]int{} // section adornments in order of appearance
+
+	heading := func(adornment, title string) {
+		level, ok := levels[adornment]
+		if !ok {
+			level = len(levels) + 1
+			levels[adornment] = level
+		}
+		out = append(out, strings.Repeat("#", min(level, 6))+" "+strings.TrimSpace(title))
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		next := ""
+		if i+1 < len(lines) {
+			next = lines[i+1]
+		}
+
+		// title with overline and underline
+		if isRSTAdornment(line) && i+2 < len(lines) && strings.TrimSpace(next) != "" &&
+			strings.TrimSpace(lines[i+2]) == strings.TrimSpace(line) {
+			heading("over"+line[:1], next)
+			i += 2
+			continue
+		}
+		// title with underline
+		if strings.TrimSpace(line) != "" && !isRSTAdornment(line) && isRSTAdornment(next) &&
+			len(strings.TrimSpace(next)) >= len(strings.TrimSpace(line)) && !rstListMarker.MatchString(line) {
+			heading(next[:1], line)
+			i++
+			continue
+		}
+
+		if m := rstDirective.FindStringSubmatch(line); m != nil {
+			body, end := rstIndented(lines, i+1)
+			switch m[1] {
+			case "code", "code-block", "sourcecode":
+				out = append(out, "```"+m[2])
+				out = append(out, body...)
+				out = append(out, "```", "")
+			case "image", "figure":
+				out = append(out, fmt.Sprintf("![](%v)", m[2]), "")
+			case "note", "tip", "warning", "important", "caution", "attention", "hint", "danger", "error":
+				text := strings.TrimSpace(m[2] + " " + strings.Join(body, " "))
+				out = append(out, fmt.Sprintf("> **%v:** %v", strings.ToUpper(m[1][:1])+m[1][1:], rstInline(text)), "")
+			}
+			i = end - 1
+			continue
+		}
+		if strings.HasPrefix(line, "..") { // comments and unknown markup
+			_, end := rstIndented(lines, i+1)
+			i = end - 1
+			continue
+		}
+
+		// a paragraph ending in :: introduces a literal block
+		if strings.HasSuffix(strings.TrimSpace(line), "::") {
+			text := strings.TrimSuffix(strings.TrimRight(line, " "), ":")
+			if strings.TrimSpace(text) == ":" {
+				text = ""
+			}
+			out = append(out, rstInline(text))
+			body, end := rstIndented(lines, i+1)
+			if len(body) > 0 {
+				out = append(out, "", "```")
+				out = append(out, body...)
+				out = append(out, "```", "")
+				i = end - 1
+			}
+			continue
+		}
+
+		out = append(out, rstInline(line))
+	}
+	return strings.Join(out, "\n")
+}
+
+// rstIndented returns the indented block starting at line start without its
+// indentation, skipping blank lines before it, and the line after it.
+func rstIndented(lines []string, start int) ([]string, int) {
+	i := start
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	var block []string
+	indent := -1
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" {
+			block = append(block, "")
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if n == 0 {
+			break
+		}
+		if indent < 0 || n < indent {
+			indent = n
+		}
+		block = append(block, line)
+	}
+	for len(block) > 0 && block[len(block)-1] == "" {
+		block = block[:len(block)-1]
+	}
+	for j, line := range block {
+		if len(line) >= indent && indent > 0 {
+			block[j] = line[indent:]
+		}
+	}
+	if len(block) == 0 {
+		return nil, start
+	}
+	return block, i
+}
+
+// isRSTAdornment reports whether line over- or underlines a section title.
+func isRSTAdornment(line string) bool {
+	line = strings.TrimRight(line, " ")
+	if len(line) < 3 || !strings.ContainsRune("=-~^\"'`#*+:.", rune(line[0])) {
+		return false
+	}
+	return strings.Count(line, line[:1]) == len(line)
+}
+
+func rstInline(line string) string {
+	line = rstLink.ReplaceAllString(line, "[$1]($2)")
+	line = rstLiteral.ReplaceAllString(line, "`$1`")
+	return rstRole.ReplaceAllString(line, "`$1`")
+}
+
+var (
+	adocHeading   = regexp.MustCompile(`^(={1,6})\s+(.*)$`)
+	adocAttribute = regexp.MustCompile(`^:[\w-]+:.*$`)
+	adocBlockAttr = regexp.MustCompile(`^\[(.*)\]$`)
+	adocList      = regexp.MustCompile(`^(\*+|\.+|-)\s+(.*)$`)
+	adocImage     = regexp.MustCompile(`image::?([^\[\s]+)\[([^\]]*)\]`)
+	adocLink      = regexp.MustCompile(`(?:link:)?((?:https?|ftp|mailto|file):[^\[\s]*|[^\[\s]+\.(?:adoc|md|html))\[([^\]]*)\]`)
+	adocXref      = regexp.MustCompile(`<<([^,>]+),\s*([^>]+)>>`)
+	adocBold      = regexp.MustCompile(`(^|[^\w*])\*([^*\s](?:[^*]*[^*\s])?)\*([^\w*]|$)`)
+	adocAdmonish  = regexp.MustCompile(`^(NOTE|TIP|IMPORTANT|WARNING|CAUTION):\s+(.*)$`)
+)
+
+// asciidocToMarkdown converts AsciiDoc headings, lists, listing and literal
+// blocks, images, links, bold text and admonitions to markdown.
+func asciidocToMarkdown(source string) string {
+	lines := strings.Split(source, "\n")
+	var out []string
+	lang := ""
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "----" || trimmed == "....":
+			out = append(out, "```"+lang)
+			for i++; i < len(lines) && strings.TrimSpace(lines[i]) != trimmed; i++ {
+				out = append(out, lines[i])
+			}
+			out = append(out, "```")
+			lang = ""
+			continue
+		case strings.HasPrefix(trimmed, "//"), adocAttribute.MatchString(trimmed):
+			continue
+		case adocBlockAttr.MatchString(trimmed):
+			// [source,go] picks the language of the next listing block
+			attrs := strings.Split(adocBlockAttr.FindStringSubmatch(trimmed)[1], ",")
+			if len(attrs) > 1 && strings.TrimSpace(attrs[0]) == "source" {
+				lang = strings.TrimSpace(attrs[1])
+			}
+			continue
+		}
+
+		if m := adocHeading.FindStringSubmatch(line); m != nil {
+			out = append(out, strings.Repeat("#", len(m[1]))+" "+adocInline(m[2]))
+			continue
+		}
+		if m := adocAdmonish.FindStringSubmatch(line); m != nil {
+			// a blank line ends the quote, which would take in the next line
+			out = append(out, fmt.Sprintf("> **%v%v:** %v", m[1][:1], strings.ToLower(m[1][1:]), adocInline(m[2])), "")
+			continue
+		}
+		if m := adocList.FindStringSubmatch(line); m != nil {
+			depth := max(len(m[1])-1, 0)
+			marker := "-"
+			if m[1][0] == '.' {
+				marker = "1."
+			}
+			out = append(out, strings.Repeat("  ", depth)+marker+" "+adocInline(m[2]))
+			continue
+		}
+		out = append(out, adocInline(line))
+	}
+	return strings.Join(out, "\n")
+}
+
+func adocInline(line string) string {
+	line = adocImage.ReplaceAllString(line, "![$2]($1)")
+	line = adocLink.ReplaceAllStringFunc(line, func(s string) string {
+		m := adocLink.FindStringSubmatch(s)
+		text := m[2]
+		if text == "" {
+			text = m[1]
+		}
+		return fmt.Sprintf("[%v](%v)", text, m[1])
+	})
+	line = adocXref.ReplaceAllString(line, "[$2](#$1)")
+	return adocBold.ReplaceAllString(line, "$1**$2**$3")
+}
+
+// htmlToMarkdown converts the basic HTML elements for text, headings, lists,
+// links, images and code to markdown. Scripts, styles and the head are left
+// out.
+func htmlToMarkdown(source string) string {
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(source))
+	var lists []string // "-" or "1." for each open list
+	var href []string  // destinations of open links
+	skip := 0          // depth of elements whose text isn't shown
+	code := 0          // depth of inline code, whose text is literal
+	pre := false
+
+	newline := func(n int) {
+		text := b.String()
+		for have := len(text) - len(strings.TrimRight(text, "\n")); have < n && len(text) > 0; have++ {
+			b.WriteString("\n")
+		}
+	}
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		token := z.Token()
+		name := token.Data
+		switch tt {
+		case html.TextToken:
+			if skip > 0 {
+				continue
+			}
+			if pre {
+				b.WriteString(token.Data)
+				continue
+			}
+			text := strings.Join(strings.Fields(token.Data), " ")
+			if text == "" {
+				continue
+			}
+			if code == 0 {
+				s := b.String()
+				text = escapeMarkdown(text, s == "" || strings.HasSuffix(s, "\n"))
+			}
+			if strings.HasPrefix(token.Data, " ") || strings.HasPrefix(token.Data, "\n") {
+				if s := b.String(); s != "" && !strings.HasSuffix(s, " ") && !strings.HasSuffix(s, "\n") {
+					text = " " + text
+				}
+			}
+			if strings.HasSuffix(token.Data, " ") || strings.HasSuffix(token.Data, "\n") {
+				text += " "
+			}
+			b.WriteString(text)
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			switch name {
+			case "script", "style", "head", "title":
+				if tt == html.StartTagToken {
+					skip++
+				}
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				newline(2)
+				b.WriteString(strings.Repeat("#", int(name[1]-'0')) + " ")
+			case "p", "div", "section", "article", "table":
+				newline(2)
+			case "br", "tr":
+				newline(1)
+			case "td", "th":
+				b.WriteString(" ")
+			case "hr":
+				newline(2)
+				b.WriteString("---")
+				newline(2)
+			case "ul", "ol":
+				newline(1)
+				marker := "-"
+				if name == "ol" {
+					marker = "1."
+				}
+				lists = append(lists, marker)
+			case "li":
+				newline(1)
+				marker := "-"
+				if len(lists) > 0 {
+					marker = lists[len(lists)-1]
+				}
+				b.WriteString(strings.Repeat("  ", max(len(lists)-1, 0)) + marker + " ")
+			case "blockquote":
+				newline(2)
+				b.WriteString("> ")
+			case "strong", "b":
+				b.WriteString("**")
+			case "em", "i":
+				b.WriteString("*")
+			case "code":
+				if !pre {
+					code++
+					b.WriteString("`")
+				}
+			case "pre":
+				newline(2)
+				b.WriteString("```\n")
+				pre = true
+			case "a":
+				href = append(href, htmlAttr(token, "href"))
+				b.WriteString("[")
+			case "img":
+				fmt.Fprintf(&b, "![%v](%v)", escapeMarkdown(htmlAttr(token, "alt"), false), htmlAttr(token, "src"))
+			}
+
+		case html.EndTagToken:
+			switch name {
+			case "script", "style", "head", "title":
+				skip = max(skip-1, 0)
+			case "h1", "h2", "h3", "h4", "h5", "h6", "p", "div", "section", "article", "table", "blockquote":
+				newline(2)
+			case "ul", "ol":
+				if len(lists) > 0 {
+					lists = lists[:len(lists)-1]
+				}
+				newline(2)
+			case "strong", "b":
+				b.WriteString("**")
+			case "em", "i":
+				b.WriteString("*")
+			case "code":
+				if !pre {
+					code = max(code-1, 0)
+					b.WriteString("`")
+				}
+			case "pre":
+				newline(1)
+				b.WriteString("```")
+				newline(2)
+				pre = false
+			case "a":
+				dest := ""
+				if len(href) > 0 {
+					dest = href[len(href)-1]
+					href = href[:len(href)-1]
+				}
+				fmt.Fprintf(&b, "](%v)", dest)
+			}
+		}
+	}
+	return b.String()
+}
+
+// markdownEscaper escapes the characters that are markdown syntax anywhere
+// in a line.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "!", `\!`, "|", `\|`,
+)
+
+var markdownListStart = regexp.MustCompile(`^([-+]|\d+[.)])(\s|$)`)
+
+// escapeMarkdown escapes text so that markdown shows it as is. At the start
+// of a line list markers are escaped too.
+func escapeMarkdown(text string, lineStart bool) string {
+	text = markdownEscaper.Replace(text)
+	if lineStart {
+		if m := markdownListStart.FindStringSubmatchIndex(text); m != nil {
+			end := m[3]
+			text = text[:end-1] + `\` + text[end-1:]
+		}
+	}
+	return text
+}
+
+func htmlAttr(t html.Token, key string) string {
+	for _, a := range t.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+package widget
+
+import "testing"
+
+func TestRSTToMarkdown(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"empty", "", ""},
+		{"underlined headings", "Title\n=====\n\nSection\n-------\n\nText", "# Title\n\n## Section\n\nText"},
+		{"overlined heading", "=====\nTitle\n=====\n\nSub\n===", "# Title\n\n## Sub"},
+		{"lists", "- item\n- other\n\n1. one\n2. two", "- item\n- other\n\n1. one\n2. two"},
+		{"list item isn't a title", "- a\n---", "- a\n---"},
+		{"short adornment", "==\nx", "==\nx"},
+		{"note", ".. note:: Be careful\n   with this.\n\nafter", "> **Note:** Be careful with this.\n\nafter"},
+		{"warning with body only", ".. warning::\n\n   Body only.", "> **Warning:** Body only.\n"},
+		{"code directive", ".. code:: go\n\n   x := 1\n\nafter", "```go\nx := 1\n```\n\nafter"},
+		{"image directive", ".. image:: pic.png\n", "![](pic.png)\n\n"},
+		{"literal block", "Example::\n\n   literal\n\nafter", "Example:\n\n```\nliteral\n```\n\nafter"},
+		{"bare literal marker", "::\n\n   literal", "\n\n```\nliteral\n```\n"},
+		{"literal marker without block", "Trailing::", "Trailing:"},
+		{"comment", ".. comment\n   more\n\ntext", "text"},
+		{"inline markup", "See `Go <https://go.dev>`_ and ``code`` and :func:`f`.", "See [Go](https://go.dev) and `code` and `f`."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rstToMarkdown(tt.in); got != tt.want {
+				t.Errorf("rstToMarkdown(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAsciidocToMarkdown(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"empty", "", ""},
+		{"headings", "= Title\n\n== Section\n\ntext", "# Title\n\n## Section\n\ntext"},
+		{"lists", "* one\n** two\n. first\n.. second\n- dash", "- one\n  - two\n1. first\n  1. second\n- dash"},
+		{"admonitions", "NOTE: Be careful\nWARNING: *bad*", "> **Note:** Be careful\n\n> **Warning:** **bad**\n"},
+		{"source block", "[source,go]\n----\nx := 1\n----\nafter", "```go\nx := 1\n```\nafter"},
+		{"literal block", "....\nliteral\n....", "```\nliteral\n```"},
+		{"unterminated block", "----\nunterminated", "```\nunterminated\n```"},
+		{"images and links", "image::pic.png[A pic]\nSee https://go.dev[Go] and link:doc.adoc[] and <<intro, Intro>>.",
+			"![A pic](pic.png)\nSee [Go](https://go.dev) and [doc.adoc](doc.adoc) and [Intro](#intro)."},
+		{"bold", "a *bold* b and 2*3*4", "a **bold** b and 2*3*4"},
+		{"attributes and comments", ":toc:\n// comment\ntext", "text"},
+		{"block attributes", "[quote]\ntext", "text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := asciidocToMarkdown(tt.in); got != tt.want {
+				t.Errorf("asciidocToMarkdown(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"empty", "", ""},
+		{"heading and inline markup", "<h1>Title</h1><p>Some <b>bold</b> and <em>em</em> text.</p>", "# Title\n\nSome **bold** and *em* text.\n\n"},
+		{"nested lists", "<ul><li>one</li><li>two<ol><li>a</li></ol></li></ul>", "- one\n- two\n  1. a\n\n"},
+		{"link and image", `<p>a <a href="x.md">link</a> and <img src="p.png" alt="pic"></p>`, "a [link](x.md) and ![pic](p.png)\n\n"},
+		{"pre", "<pre><code>x := *p\n</code></pre><p>after</p>", "```\nx := *p\n```\n\nafter\n\n"},
+		{"escaped text", "<p>2 * 3 * 4 and a_b_c, #1 and [x]</p>", `2 \* 3 \* 4 and a\_b\_c, \#1 and \[x\]` + "\n\n"},
+		{"escaped list markers", "<p>- not a list</p><p>1. nor this</p>", "\\- not a list\n\n1\\. nor this\n\n"},
+		{"inline code isn't escaped", "<p>use <code>a*b</code></p>", "use `a*b`\n\n"},
+		{"hidden elements", "<head><title>T</title><style>p{}</style></head><script>x<y</script><p>shown</p>", "shown\n\n"},
+		{"blocks", "<blockquote>quoted</blockquote><hr><p>x<br>y</p>", "> quoted\n\n---\n\nx\ny\n\n"},
+		{"unclosed tags", "<p>unclosed <b>bold", "unclosed **bold"},
+		{"stray end tags", "</p></li>stray", "stray"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := htmlToMarkdown(tt.in); got != tt.want {
+				t.Errorf("htmlToMarkdown(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	tests := []struct {
+		in        string
+		lineStart bool
+		want      string
+	}{
+		{"plain text", true, "plain text"},
+		{`a\b`, false, `a\\b`},
+		{"`x` <y> !z |w|", false, "\\`x\\` \\<y\\> \\!z \\|w\\|"},
+		{"- item", true, `\- item`},
+		{"- item", false, "- item"},
+		{"+", true, `\+`},
+		{"12) twelve", true, `12\) twelve`},
+		{"3.14 pi", true, "3.14 pi"},
+	}
+	for _, tt := range tests {
+		if got := escapeMarkdown(tt.in, tt.lineStart); got != tt.want {
+			t.Errorf("escapeMarkdown(%q, %v) = %q, want %q", tt.in, tt.lineStart, got, tt.want)
+		}
+	}
+}
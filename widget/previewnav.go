@@ -0,0 +1,255 @@
+package widget
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// previewHeading is a heading in a rendered document, the outline scrolls to
+// its section.
+type previewHeading struct {
+	title   string
+	level   int
+	section fyne.CanvasObject
+}
+
+// outliner is implemented by renderers whose previews have headings.
+type outliner interface {
+	outline() []previewHeading
+}
+
+// previewPane holds the preview and takes keyboard input once it is clicked,
+// to scroll it like a pager:
+//
+//	j, k, Down, Up        one line
+//	Ctrl-d, Ctrl-u        half a page
+//	PageDown, PageUp      a page
+//	gg, G, Home, End      to the top or bottom
+//	Esc                   back to the editor
+//	q                     close the preview
+type previewPane struct {
+	widget.BaseWidget
+	e       *Editor
+	content fyne.CanvasObject
+	pendG   bool // g was typed, another g goes to the top
+}
+
+func newPreviewPane(e *Editor, content fyne.CanvasObject) *previewPane {
+	p := &previewPane{e: e, content: content}
+	p.ExtendBaseWidget(p)
+	return p
+}
+
+func (p *previewPane) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(p.content)
+}
+
+// Tapped focuses the pane for scrolling with the keyboard.
+func (p *previewPane) Tapped(*fyne.PointEvent) {
+	if c := fyne.CurrentApp().Driver().CanvasForObject(p); c != nil {
+		c.Focus(p)
+	}
+}
+
+func (p *previewPane) FocusGained() {}
+func (p *previewPane) FocusLost()   {}
+
+func (p *previewPane) TypedRune(r rune) {
+	pendG := p.pendG
+	p.pendG = false
+	switch r {
+	case 'j':
+		p.e.scrollPreview(previewLineHeight())
+	case 'k':
+		p.e.scrollPreview(-previewLineHeight())
+	case 'g':
+		if pendG {
+			p.e.scrollPreviewTo(0)
+		} else {
+			p.pendG = true
+		}
+	case 'G':
+		p.e.scrollPreviewTo(-1)
+	case 'q':
+		p.e.ExitPreviewMode()
+		p.focusEditor()
+	}
+}
+
+func (p *previewPane) TypedKey(ev *fyne.KeyEvent) {
+	p.pendG = false
+	page := p.e.previewScroll().Size().Height
+	switch ev.Name {
+	case fyne.KeyDown:
+		p.e.scrollPreview(previewLineHeight())
+	case fyne.KeyUp:
+		p.e.scrollPreview(-previewLineHeight())
+	case fyne.KeyPageDown:
+		p.e.scrollPreview(page)
+	case fyne.KeyPageUp:
+		p.e.scrollPreview(-page)
+	case fyne.KeyHome:
+		p.e.scrollPreviewTo(0)
+	case fyne.KeyEnd:
+		p.e.scrollPreviewTo(-1)
+	case fyne.KeyEscape:
+		p.focusEditor()
+	}
+}
+
+// KeyDown handles Ctrl-d and Ctrl-u, which fyne doesn't type as runes.
+func (p *previewPane) KeyDown(ev *fyne.KeyEvent) {
+	d, ok := fyne.CurrentApp().Driver().(desktop.Driver)
+	if !ok || d.CurrentKeyModifiers() != fyne.KeyModifierControl {
+		return
+	}
+	half := p.e.previewScroll().Size().Height / 2
+	switch ev.Name {
+	case fyne.KeyD:
+		p.e.scrollPreview(half)
+	case fyne.KeyU:
+		p.e.scrollPreview(-half)
+	}
+}
+
+func (p *previewPane) KeyUp(*fyne.KeyEvent) {}
+
+func (p *previewPane) focusEditor() {
+	if c := fyne.CurrentApp().Driver().CanvasForObject(p.e); c != nil {
+		c.Focus(p.e)
+	}
+}
+
+func previewLineHeight() float32 {
+	return fyne.MeasureText("M", theme.TextSize(), fyne.TextStyle{}).Height
+}
+
+// previewScroll returns the scroll container of what the preview shows.
+func (e *Editor) previewScroll() *container.Scroll {
+	p := &e.preview
+	if len(p.body.Objects) > 0 && p.body.Objects[0] == p.image.view {
+		return p.image.scroll
+	}
+	return p.scroll
+}
+
+// scrollPreview scrolls the preview down by dy, or up if it is negative.
+func (e *Editor) scrollPreview(dy float32) {
+	s := e.previewScroll()
+	e.scrollPreviewTo(max(s.Offset.Y+dy, 0))
+}
+
+// scrollPreviewTo scrolls the preview to y, or to the bottom if y is
+// negative.
+func (e *Editor) scrollPreviewTo(y float32) {
+	s := e.previewScroll()
+	bottom := max(s.Content.Size().Height-s.Size().Height, 0)
+	if y < 0 || y > bottom {
+		y = bottom
+	}
+	s.Offset.Y = y
+	s.Refresh()
+}
+
+// updateOutline lists the headings of the document r rendered in the
+// outline menu, which is hidden if there are none.
+func (e *Editor) updateOutline(r PreviewRenderer) {
+	p := &e.preview
+	p.headings = nil
+	if o, ok := r.(outliner); ok {
+		p.headings = o.outline()
+	}
+	if len(p.headings) == 0 {
+		p.outlineBar.Hide()
+	} else {
+		p.outlineBar.Show()
+	}
+}
+
+// showOutline pops up the headings of the document, choosing one scrolls to
+// its section.
+func (e *Editor) showOutline() {
+	p := &e.preview
+	c := fyne.CurrentApp().Driver().CanvasForObject(p.outline)
+	if c == nil || len(p.headings) == 0 {
+		return
+	}
+
+	items := make([]*fyne.MenuItem, len(p.headings))
+	for i, h := range p.headings {
+		items[i] = fyne.NewMenuItem(strings.Repeat("    ", h.level-1)+h.title, func() {
+			e.scrollPreviewTo(h.section.Position().Y)
+		})
+	}
+	menu := fyne.NewMenu("", items...)
+	widget.ShowPopUpMenuAtRelativePosition(menu, c, fyne.NewPos(0, p.outline.Size().Height), p.outline)
+}
+
+// openPreviewLink opens a link tapped in the preview of the file at from:
+// #fragments at their heading, local files in nvim with the preview moving
+// along, and anything else with the system's handler, e.g. the browser.
+func (e *Editor) openPreviewLink(u *url.URL, from string) {
+	switch {
+	case u.Scheme == "" && u.Path == "" && u.Fragment != "":
+		e.scrollToAnchor(u.Fragment)
+
+	case u.Scheme == "" || u.Scheme == "file":
+		path := u.Path
+		if !filepath.IsAbs(path) {
+			if from == "" {
+				e.debug("can't open relative link without a file", "link", u)
+				return
+			}
+			path = filepath.Join(filepath.Dir(from), path)
+		}
+		go func() {
+			err := e.Nvim.ExecLua(fileCommand, nil, "drop", []string{path})
+			if err != nil {
+				e.debug("error opening link", "link", u, "error", err)
+				return
+			}
+			err = e.EnterPreviewMode()
+			if err != nil {
+				e.debug("error previewing link", "link", u, "error", err)
+			}
+		}()
+
+	default:
+		err := fyne.CurrentApp().OpenURL(u)
+		if err != nil {
+			e.debug("error opening link", "link", u, "error", err)
+		}
+	}
+}
+
+// scrollToAnchor scrolls to the heading whose anchor is fragment, anchors are
+// made from headings like GitHub does.
+func (e *Editor) scrollToAnchor(fragment string) {
+	for _, h := range e.preview.headings {
+		if headingAnchor(h.title) == strings.ToLower(fragment) {
+			e.scrollPreviewTo(h.section.Position().Y)
+			return
+		}
+	}
+}
+
+func headingAnchor(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(title)) {
+		switch {
+		case r == ' ' || r == '-':
+			b.WriteRune('-')
+		case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
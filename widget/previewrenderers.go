@@ -0,0 +1,438 @@
+package widget
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/yuin/goldmark/util"
+	"gopkg.in/yaml.v3"
+)
+
+// PreviewRenderer renders a buffer in the preview, see
+// Editor.PreviewRenderers.
+type PreviewRenderer interface {
+	// Render returns the preview of doc. It is called again after every
+	// change to the buffer, and may return the same object updated to keep
+	// state such as open branches. The preview scrolls the object vertically
+	// if it is taller than the preview.
+	Render(doc PreviewDocument) fyne.CanvasObject
+}
+
+// PreviewDocument is a buffer to be rendered by a PreviewRenderer.
+type PreviewDocument struct {
+	Lines    []string
+	Filetype string // the buffer's 'filetype'
+	Path     string // the buffer's file, empty if it has none
+
+	// OpenLink opens a link tapped in the preview, relative to Path.
+	OpenLink func(*url.URL)
+}
+
+// Text returns the document's lines joined by newlines.
+func (d PreviewDocument) Text() string {
+	return strings.Join(d.Lines, "\n")
+}
+
+// defaultPreviewRenderers returns the renderers for the filetypes the preview
+// supports out of the box.
+func defaultPreviewRenderers() map[string]PreviewRenderer {
+	asciidoc := &markdownRenderer{convert: asciidocToMarkdown}
+	html := &markdownRenderer{convert: htmlToMarkdown}
+	return map[string]PreviewRenderer{
+		"markdown":    &markdownRenderer{},
+		"rst":         &markdownRenderer{convert: rstToMarkdown},
+		"asciidoc":    asciidoc,
+		"asciidoctor": asciidoc,
+		"html":        html,
+		"xhtml":       html,
+		"json":        &treeRenderer{parse: parseJSONTree},
+		"yaml":        &treeRenderer{parse: parseYAMLTree},
+		"csv":         &tableRenderer{comma: ','},
+		"tsv":         &tableRenderer{comma: '\t'},
+	}
+}
+
+// previewRenderer returns the renderer for filetype, buffers of filetypes
+// without one are rendered as markdown.
+func (e *Editor) previewRenderer(filetype string) PreviewRenderer {
+	if r, ok := e.PreviewRenderers[filetype]; ok {
+		return r
+	}
+	if e.preview.fallback == nil {
+		e.preview.fallback = &markdownRenderer{}
+	}
+	return e.preview.fallback
+}
+
+// markdownRenderer renders markdown with RichText, other markup is converted
+// to markdown first. Each heading starts a RichText of its own, so that the
+// outline can scroll to it.
+type markdownRenderer struct {
+	convert  func(source string) string
+	box      *fyne.Container
+	headings []previewHeading
+}
+
+func (r *markdownRenderer) Render(doc PreviewDocument) fyne.CanvasObject {
+	source := doc.Text()
+	if r.convert != nil {
+		source = r.convert(source)
+	}
+	segments := widget.NewRichTextFromMarkdown(source).Segments
+	if doc.Path != "" {
+		resolveImages(source, segments, filepath.Dir(doc.Path))
+	}
+	walkSegments(segments, func(s widget.RichTextSegment) {
+		switch s := s.(type) {
+		case *widget.TextSegment:
+			// RichText keeps the backslashes of escaped characters, except
+			// in code, where they are literal
+			if !s.Style.TextStyle.Monospace {
+				s.Text = string(util.UnescapePunctuations([]byte(s.Text)))
+			}
+		case *widget.HyperlinkSegment:
+			s.Text = string(util.UnescapePunctuations([]byte(s.Text)))
+			if doc.OpenLink != nil && s.URL != nil {
+				u := s.URL
+				s.OnTapped = func() { doc.OpenLink(u) }
+			}
+		}
+	})
+
+	if r.box == nil {
+		r.box = container.New(layout.NewCustomPaddedVBoxLayout(0))
+	}
+	r.headings = nil
+	var sections []fyne.CanvasObject
+	var section []widget.RichTextSegment
+	flush := func() {
+		if len(section) == 0 {
+			return
+		}
+		text := widget.NewRichText(section...)
+		text.Wrapping = fyne.TextWrapWord
+		sections = append(sections, text)
+		if level := headingLevel(section[0]); level > 0 {
+			r.headings = append(r.headings, previewHeading{
+				title:   section[0].(*widget.TextSegment).Text,
+				level:   level,
+				section: text,
+			})
+		}
+		section = nil
+	}
+	for _, s := range segments {
+		if headingLevel(s) > 0 {
+			flush()
+		}
+		section = append(section, s)
+	}
+	flush()
+	r.box.Objects = sections
+	r.box.Refresh()
+	return r.box
+}
+
+func (r *markdownRenderer) outline() []previewHeading {
+	return r.headings
+}
+
+// headingLevel returns the level of the heading s, or 0 if it isn't one.
+// RichText renders headings below level 2 alike, as bold paragraphs.
+func headingLevel(s widget.RichTextSegment) int {
+	text, ok := s.(*widget.TextSegment)
+	if !ok || text.Text == "" {
+		return 0
+	}
+	switch {
+	case text.Style == widget.RichTextStyleHeading:
+		return 1
+	case text.Style == widget.RichTextStyleSubHeading:
+		return 2
+	case !text.Style.Inline && text.Style.TextStyle.Bold && text.Style.SizeName == theme.SizeNameText:
+		return 3
+	}
+	return 0
+}
+
+// maxColumnWidth limits how wide the table preview makes a column to fit
+// its cells.
+const maxColumnWidth = 300
+
+// tableRenderer shows CSV or TSV in a table, with the first row as header.
+type tableRenderer struct {
+	comma rune
+	rows  [][]string
+	cols  int
+	table *widget.Table
+}
+
+func (r *tableRenderer) Render(doc PreviewDocument) fyne.CanvasObject {
+	reader := csv.NewReader(strings.NewReader(doc.Text()))
+	reader.Comma = r.comma
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	r.rows = nil
+	r.cols = 0
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			continue
+		}
+		if err != nil {
+			break
+		}
+		r.rows = append(r.rows, row)
+		r.cols = max(r.cols, len(row))
+	}
+
+	if r.table == nil {
+		r.table = widget.NewTable(r.size, r.createCell, r.updateCell)
+		r.table.StickyRowCount = 1
+	}
+	for col := 0; col < r.cols; col++ {
+		r.table.SetColumnWidth(col, r.columnWidth(col))
+	}
+	r.table.Refresh()
+	return r.table
+}
+
+func (r *tableRenderer) size() (int, int) {
+	return len(r.rows), r.cols
+}
+
+func (r *tableRenderer) createCell() fyne.CanvasObject {
+	return widget.NewLabel("")
+}
+
+func (r *tableRenderer) updateCell(id widget.TableCellID, o fyne.CanvasObject) {
+	label := o.(*widget.Label)
+	label.TextStyle.Bold = id.Row == 0
+	label.SetText(r.cell(id.Row, id.Col))
+}
+
+func (r *tableRenderer) cell(row, col int) string {
+	if row >= len(r.rows) || col >= len(r.rows[row]) {
+		return ""
+	}
+	return r.rows[row][col]
+}
+
+func (r *tableRenderer) columnWidth(col int) float32 {
+	var width float32
+	for row := range r.rows {
+		style := fyne.TextStyle{Bold: row == 0}
+		width = max(width, fyne.MeasureText(r.cell(row, col), theme.TextSize(), style).Width)
+	}
+	return min(width, maxColumnWidth) + 2*theme.InnerPadding()
+}
+
+// treeNode is a value in a JSON or YAML document, with its children in the
+// document's order.
+type treeNode struct {
+	label    string
+	children []*treeNode
+}
+
+// treeRenderer shows JSON or YAML as a collapsible tree. The tree is kept
+// across changes, so branches stay open as long as they exist.
+type treeRenderer struct {
+	parse func(source []byte) (*treeNode, error)
+	nodes map[widget.TreeNodeID]*treeNode
+	tree  *widget.Tree
+	err   *widget.Label
+}
+
+func (r *treeRenderer) Render(doc PreviewDocument) fyne.CanvasObject {
+	root, err := r.parse([]byte(doc.Text()))
+	if err != nil {
+		if r.err == nil {
+			r.err = widget.NewLabel("")
+			r.err.Wrapping = fyne.TextWrapWord
+		}
+		r.err.SetText(err.Error())
+		return r.err
+	}
+
+	// nodes are identified by their path from the root
+	r.nodes = map[widget.TreeNodeID]*treeNode{"": root}
+	var index func(id widget.TreeNodeID, n *treeNode)
+	index = func(id widget.TreeNodeID, n *treeNode) {
+		for i, child := range n.children {
+			childID := id + "/" + strconv.Itoa(i)
+			r.nodes[childID] = child
+			index(childID, child)
+		}
+	}
+	index("", root)
+
+	if r.tree == nil {
+		r.tree = widget.NewTree(r.childIDs, r.isBranch, r.createNode, r.updateNode)
+		for _, id := range r.childIDs("") {
+			r.tree.OpenBranch(id)
+		}
+	}
+	r.tree.Refresh()
+	return r.tree
+}
+
+func (r *treeRenderer) childIDs(id widget.TreeNodeID) []widget.TreeNodeID {
+	n, ok := r.nodes[id]
+	if !ok {
+		return nil
+	}
+	ids := make([]widget.TreeNodeID, len(n.children))
+	for i := range n.children {
+		ids[i] = id + "/" + strconv.Itoa(i)
+	}
+	return ids
+}
+
+func (r *treeRenderer) isBranch(id widget.TreeNodeID) bool {
+	n, ok := r.nodes[id]
+	return ok && len(n.children) > 0
+}
+
+func (r *treeRenderer) createNode(branch bool) fyne.CanvasObject {
+	return widget.NewLabel("")
+}
+
+func (r *treeRenderer) updateNode(id widget.TreeNodeID, branch bool, o fyne.CanvasObject) {
+	if n, ok := r.nodes[id]; ok {
+		o.(*widget.Label).SetText(n.label)
+	}
+}
+
+var errJSONTrailingData = errors.New("unexpected data after the top-level value")
+
+// parseJSONTree parses a JSON document keeping the order of object keys.
+func parseJSONTree(source []byte) (*treeNode, error) {
+	root := &treeNode{}
+	if len(bytes.TrimSpace(source)) == 0 {
+		return root, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(source))
+	dec.UseNumber()
+	err := parseJSONValue(dec, root, "")
+	if err == nil {
+		if _, tokenErr := dec.Token(); !errors.Is(tokenErr, io.EOF) {
+			err = errJSONTrailingData
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing JSON: %w", err)
+	}
+	return root, nil
+}
+
+// parseJSONValue reads the next value from dec into n, prefixing its label
+// with key.
+func parseJSONValue(dec *json.Decoder, n *treeNode, key string) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := t.(json.Delim)
+	if !ok {
+		n.label = key + jsonScalar(t)
+		return nil
+	}
+
+	for dec.More() {
+		child := &treeNode{}
+		childKey := fmt.Sprintf("[%d]: ", len(n.children))
+		if delim == '{' {
+			k, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			childKey = fmt.Sprint(k) + ": "
+		}
+		err := parseJSONValue(dec, child, childKey)
+		if err != nil {
+			return err
+		}
+		n.children = append(n.children, child)
+	}
+	if _, err := dec.Token(); err != nil { // closing delimiter
+		return err
+	}
+
+	if delim == '{' {
+		n.label = fmt.Sprintf("%v{%d}", key, len(n.children))
+	} else {
+		n.label = fmt.Sprintf("%v[%d]", key, len(n.children))
+	}
+	return nil
+}
+
+func jsonScalar(t json.Token) string {
+	switch t := t.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(t)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// parseYAMLTree parses a YAML document keeping the order of mapping keys.
+func parseYAMLTree(source []byte) (*treeNode, error) {
+	var doc yaml.Node
+	err := yaml.Unmarshal(source, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing YAML: %w", err)
+	}
+	root := &treeNode{}
+	if len(doc.Content) > 0 {
+		yamlNode(doc.Content[0], root, "")
+	}
+	return root, nil
+}
+
+// yamlNode converts y to n, prefixing its label with key.
+func yamlNode(y *yaml.Node, n *treeNode, key string) {
+	if y.Kind == yaml.AliasNode && y.Alias != nil {
+		n.label = key + "*" + y.Value
+		return
+	}
+
+	switch y.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(y.Content); i += 2 {
+			child := &treeNode{}
+			yamlNode(y.Content[i+1], child, y.Content[i].Value+": ")
+			n.children = append(n.children, child)
+		}
+		n.label = fmt.Sprintf("%v{%d}", key, len(n.children))
+	case yaml.SequenceNode:
+		for i, item := range y.Content {
+			child := &treeNode{}
+			yamlNode(item, child, fmt.Sprintf("[%d]: ", i))
+			n.children = append(n.children, child)
+		}
+		n.label = fmt.Sprintf("%v[%d]", key, len(n.children))
+	default:
+		n.label = key + y.Value
+	}
+}
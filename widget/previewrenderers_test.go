@@ -0,0 +1,149 @@
+package widget
+
+import (
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+// treeString returns the labels of n and its children, indented by depth.
+func treeString(n *treeNode) string {
+	var b strings.Builder
+	var write func(n *treeNode, depth int)
+	write = func(n *treeNode, depth int) {
+		b.WriteString(strings.Repeat("  ", depth) + n.label + "\n")
+		for _, child := range n.children {
+			write(child, depth+1)
+		}
+	}
+	write(n, 0)
+	return b.String()
+}
+
+func TestParseJSONTree(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+		wantErr        bool
+	}{
+		{"object keeps key order", `{"b": 1, "a": [true, null, "s"], "o": {}}`,
+			"{3}\n  b: 1\n  a: [3]\n    [0]: true\n    [1]: null\n    [2]: \"s\"\n  o: {0}\n", false},
+		{"array", `[1.5, {"x": "y"}]`, "[2]\n  [0]: 1.5\n  [1]: {1}\n    x: \"y\"\n", false},
+		{"scalar", `"str"`, "\"str\"\n", false},
+		{"empty", " \n", "\n", false},
+		{"missing value", `{"a": }`, "", true},
+		{"unterminated", `[1, 2`, "", true},
+		{"trailing data", `{"a": 1} {"b": 2}`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := parseJSONTree([]byte(tt.in))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseJSONTree(%q) = %q, want an error", tt.in, treeString(root))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseJSONTree(%q): %v", tt.in, err)
+			}
+			if got := treeString(root); got != tt.want {
+				t.Errorf("parseJSONTree(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseYAMLTree(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+		wantErr        bool
+	}{
+		{"mapping keeps key order", "b: 1\na:\n  - x\n  - y: z\n", "{2}\n  b: 1\n  a: [2]\n    [0]: x\n    [1]: {1}\n      y: z\n", false},
+		{"alias", "- &anchor 1\n- *anchor\n", "[2]\n  [0]: 1\n  [1]: *anchor\n", false},
+		{"scalar", "just text", "just text\n", false},
+		{"empty", "", "\n", false},
+		{"unterminated flow sequence", "a: [1, 2\n", "", true},
+		{"bad indentation", "a:\n b: 1\n  c: 2\n", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := parseYAMLTree([]byte(tt.in))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseYAMLTree(%q) = %q, want an error", tt.in, treeString(root))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseYAMLTree(%q): %v", tt.in, err)
+			}
+			if got := treeString(root); got != tt.want {
+				t.Errorf("parseYAMLTree(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownRendererOutline(t *testing.T) {
+	test.NewTempApp(t)
+	r := &markdownRenderer{}
+	r.Render(PreviewDocument{Lines: []string{
+		"intro",
+		"# One",
+		"text",
+		"## Two",
+		"### Three",
+		"#### Four",
+	}})
+
+	want := []struct {
+		title string
+		level int
+	}{{"One", 1}, {"Two", 2}, {"Three", 3}, {"Four", 3}}
+	headings := r.outline()
+	if len(headings) != len(want) {
+		t.Fatalf("got %d headings, want %d", len(headings), len(want))
+	}
+	for i, h := range headings {
+		if h.title != want[i].title || h.level != want[i].level {
+			t.Errorf("heading %d = %q level %d, want %q level %d", i, h.title, h.level, want[i].title, want[i].level)
+		}
+		if h.section != r.box.Objects[i+1] {
+			t.Errorf("heading %q doesn't start section %d", h.title, i+1)
+		}
+	}
+}
+
+func TestMarkdownRendererUnescapes(t *testing.T) {
+	test.NewTempApp(t)
+	r := &markdownRenderer{convert: htmlToMarkdown}
+	r.Render(PreviewDocument{Lines: []string{"<p>2 * 3 and a_b</p><p><code>a\\*b</code></p>"}})
+
+	var texts []string
+	for _, o := range r.box.Objects {
+		walkSegments(o.(*widget.RichText).Segments, func(s widget.RichTextSegment) {
+			if text, ok := s.(*widget.TextSegment); ok && text.Text != "" {
+				texts = append(texts, text.Text)
+			}
+		})
+	}
+	want := []string{"2 * 3 and a_b", `a\*b`}
+	if strings.Join(texts, "|") != strings.Join(want, "|") {
+		t.Errorf("rendered %q, want %q", texts, want)
+	}
+}
+
+func TestHeadingAnchor(t *testing.T) {
+	tests := map[string]string{
+		"Getting Started":   "getting-started",
+		" Options & flags ": "options--flags",
+		"C-d_e 2":           "c-d_e-2",
+	}
+	for title, want := range tests {
+		if got := headingAnchor(title); got != want {
+			t.Errorf("headingAnchor(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package widget
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/color"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/neovim/go-client/nvim"
+)
+
+// stderrTailLines is how much of nvim's stderr is shown when it crashes.
+const stderrTailLines = 20
+
+// embedProcAttr is set on the command from Options.Command if it has none.
+var embedProcAttr *syscall.SysProcAttr
+
+// tailBuffer keeps the last lines written to it.
+type tailBuffer struct {
+	mu    sync.Mutex
+	lines int
+	buf   []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if n := bytes.Count(t.buf, []byte("\n")); n > t.lines {
+		for ; n > t.lines; n-- {
+			t.buf = t.buf[bytes.IndexByte(t.buf, '\n')+1:]
+		}
+		t.buf = append([]byte{}, t.buf...)
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return strings.TrimSpace(string(t.buf))
+}
+
+// start connects e.Nvim to a new child process or to the server in e.opts,
+// and serves it in the background until the connection closes.
+func (e *Editor) start() error {
+	var v *nvim.Nvim
+	var err error
+	var cmd *exec.Cmd
+	switch {
+	case e.opts.ServerAddress != "":
+		e.debug("dialing nvim server", "address", e.opts.ServerAddress)
+		// cloned, the caller's slice may have room for the appended option
+		dialOptions := append(slices.Clone(e.opts.DialOptions), nvim.DialServe(false))
+		v, err = nvim.Dial(e.opts.ServerAddress, dialOptions...)
+		if err != nil {
+			return fmt.Errorf("Error connecting to nvim server %v: %w", e.opts.ServerAddress, err)
+		}
+		e.remote = true
+
+	case e.opts.Command != nil:
+		e.debug("starting nvim child process")
+		cmd = e.opts.Command()
+		if cmd.SysProcAttr == nil {
+			cmd.SysProcAttr = embedProcAttr
+		}
+		e.stderr = &tailBuffer{lines: stderrTailLines}
+		cmd.Stderr = e.stderr
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return fmt.Errorf("Error starting nvim: %w", err)
+		}
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return fmt.Errorf("Error starting nvim: %w", err)
+		}
+		err = cmd.Start()
+		if err != nil {
+			return fmt.Errorf("Error starting nvim: %w", err)
+		}
+		v, err = nvim.New(stdout, stdin, stdin, e.logf)
+		if err != nil {
+			cmd.Process.Kill()
+			cmd.Wait()
+			return fmt.Errorf("Error connecting to nvim: %w", err)
+		}
+
+	default:
+		e.debug("starting nvim child process")
+		childProcessOptions := append(slices.Clone(e.opts.ChildProcessOptions), nvim.ChildProcessServe(false))
+		v, err = nvim.NewChildProcess(childProcessOptions...)
+		if err != nil {
+			return fmt.Errorf("Error starting nvim: %w", err)
+		}
+	}
+
+	// serve compares the client it served with e.Nvim under processMu to
+	// tell a restart from a crash
+	e.processMu.Lock()
+	e.Nvim = v
+	e.processMu.Unlock()
+
+	go e.serve(v, cmd)
+	return nil
+}
+
+// serve handles messages from v until its connection closes, and shows the
+// crash overlay if that wasn't because nvim exited or the editor was closed.
+func (e *Editor) serve(v *nvim.Nvim, cmd *exec.Cmd) {
+	err := v.Serve()
+	e.debug("nvim connection closed", "error", err)
+
+	e.processMu.Lock()
+	expected := e.closed || e.exitedCleanly || v != e.Nvim
+	e.processMu.Unlock()
+
+	var exitErr error
+	if cmd != nil {
+		exitErr = cmd.Wait()
+	} else if !e.remote {
+		exitErr = v.Close()
+	}
+	if expected {
+		return
+	}
+	if !e.remote && exitErr == nil {
+		// nvim exited cleanly before its VimLeave notification was handled
+		e.exited()
+		return
+	}
+
+	var status string
+	var ee *exec.ExitError
+	switch {
+	case e.remote:
+		status = fmt.Sprintf("Lost the connection to the nvim server at %v.", e.opts.ServerAddress)
+	case errors.As(exitErr, &ee):
+		status = fmt.Sprintf("Neovim exited unexpectedly with %v.", ee.ProcessState)
+	default:
+		status = "Neovim exited unexpectedly."
+	}
+	e.info("nvim crashed", "status", status)
+	e.showCrash(status)
+}
+
+func (e *Editor) logf(format string, args ...any) {
+	e.debug(fmt.Sprintf(format, args...))
+}
+
+// Close closes the connection to nvim, an embedded nvim exits.
+func (e *Editor) Close() error {
+	e.processMu.Lock()
+	e.closed = true
+	e.processMu.Unlock()
+	return e.Nvim.Close()
+}
+
+// Restart starts a new nvim, or reconnects to the server, and attaches the
+// editor to it after nvim exited unexpectedly.
+func (e *Editor) Restart() error {
+	e.info("restarting nvim")
+	e.processMu.Lock()
+	e.exitedCleanly = false
+	e.processMu.Unlock()
+
+	old := e.Nvim
+	err := e.start()
+	if err != nil {
+		return err
+	}
+	old.Close()
+	e.reset()
+
+	clientType := nvim.EmbedderClientType
+	if e.remote {
+		clientType = nvim.UIClientType
+	}
+	err = e.attach(clientType)
+	if err != nil {
+		return err
+	}
+
+	e.hideCrash()
+	e.resizeMu.Lock()
+	e.resizedTo = [2]int{}
+	size := e.uiSize
+	e.resizeMu.Unlock()
+	e.resizeUI(size[0], size[1])
+	return nil
+}
+
+// reset forgets the grid state of the previous nvim.
+func (e *Editor) reset() {
+	e.stopScrollAnimation()
+	e.content.Rows = nil
+	e.gridCursorGoto = nil
+	e.gridLineUpdates = nil
+	e.gridScrollUpdates = nil
+	e.gridResize = nil
+	e.gridClear = 0
+	e.modeInfoSet = ModeInfoSet{}
+	e.currentMode = ModeChange{}
+	e.busy = false
+	e.cursor.row, e.cursor.col = 0, 0
+	e.previewMode = false
+	e.resetPreview()
+	// detach events of the previous nvim won't arrive
+	e.preview.mu.Lock()
+	e.preview.detaching = nil
+	e.preview.mu.Unlock()
+}
+
+// showCrash covers the editor with the exit status, the end of nvim's stderr
+// and a button to restart nvim.
+func (e *Editor) showCrash(status string) {
+	details := status
+	if e.stderr != nil {
+		if tail := e.stderr.String(); tail != "" {
+			details += "\n\n" + tail
+		}
+	}
+	text := widget.NewLabel(details)
+	text.TextStyle = fyne.TextStyle{Monospace: true}
+	text.Wrapping = fyne.TextWrapWord
+
+	label := "Restart Neovim"
+	if e.remote {
+		label = "Reconnect"
+	}
+	var restart *widget.Button
+	restart = widget.NewButton(label, func() {
+		restart.Disable()
+		go func() {
+			if err := e.Restart(); err != nil {
+				e.showCrash(err.Error())
+			}
+		}()
+	})
+	restart.Importance = widget.HighImportance
+
+	shade := canvas.NewRectangle(color.NRGBA{A: 0xc0})
+	card := container.NewStack(
+		canvas.NewRectangle(theme.OverlayBackgroundColor()),
+		container.NewPadded(container.NewBorder(nil, container.NewCenter(restart), nil, nil, text)),
+	)
+	overlay := container.NewStack(shade, container.NewPadded(container.NewCenter(card)))
+
+	e.processMu.Lock()
+	e.crash = overlay
+	e.processMu.Unlock()
+	e.Refresh()
+}
+
+func (e *Editor) hideCrash() {
+	e.processMu.Lock()
+	e.crash = nil
+	e.processMu.Unlock()
+	e.Refresh()
+}
+
+func (e *Editor) crashOverlay() fyne.CanvasObject {
+	e.processMu.Lock()
+	defer e.processMu.Unlock()
+	return e.crash
+}
@@ -0,0 +1,8 @@
+package widget
+
+import "syscall"
+
+func init() {
+	// don't open a console window for nvim
+	embedProcAttr = &syscall.SysProcAttr{HideWindow: true}
+}